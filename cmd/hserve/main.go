@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -144,15 +145,20 @@ func parseServerOptions(args []string) (server.Options, error) {
 	}
 
 	// 获取证书路径
-	certPath, keyPath := getCertificatePaths()
-
-	// 验证证书存在
-	if err := validateCertificates(certPath); err != nil {
+	certPaths, err := getCertificatePaths(flags)
+	if err != nil {
 		return server.Options{}, err
 	}
 
+	// 使用默认证书时验证证书存在
+	if !certPaths.custom {
+		if err := validateCertificates(certPaths.cert); err != nil {
+			return server.Options{}, err
+		}
+	}
+
 	// 确定服务器配置
-	config, err := buildServerConfig(flags, certPath, keyPath)
+	config, err := buildServerConfig(flags, certPaths)
 	if err != nil {
 		return server.Options{}, err
 	}
@@ -186,7 +192,7 @@ func validateCertificates(certPath string) error {
 }
 
 // buildServerConfig 构建服务器配置
-func buildServerConfig(flags serverFlags, certPath, keyPath string) (server.Options, error) {
+func buildServerConfig(flags serverFlags, certPaths certificatePaths) (server.Options, error) {
 	// 确定根目录
 	root, err := determineRootDir(flags.dir, flags.nonFlagArgs)
 	if err != nil {
@@ -197,13 +203,31 @@ func buildServerConfig(flags serverFlags, certPath, keyPath string) (server.Opti
 	readTimeoutDuration, _ := time.ParseDuration(flags.readTimeout)
 	writeTimeoutDuration, _ := time.ParseDuration(flags.writeTimeout)
 	idleTimeoutDuration, _ := time.ParseDuration(flags.idleTimeout)
+	certWatchDuration, err := time.ParseDuration(flags.certWatch)
+	if err != nil {
+		return server.Options{}, fmt.Errorf("无效的证书检查间隔: %w", err)
+	}
+	// 续期后的证书有效期为 DefaultLeafDays，阈值不小于它时每次检查都会重新签发
+	if flags.autoRenew < 0 || flags.autoRenew >= certgen.DefaultLeafDays {
+		return server.Options{}, fmt.Errorf("无效的自动续期天数 %d：应在 0～%d 之间（服务器证书有效期为 %d 天）",
+			flags.autoRenew, certgen.DefaultLeafDays-1, certgen.DefaultLeafDays)
+	}
+
+	// 加载虚拟主机配置
+	var vhosts []server.VirtualHost
+	if flags.vhosts != "" {
+		if vhosts, err = server.LoadVirtualHosts(flags.vhosts); err != nil {
+			return server.Options{}, err
+		}
+	}
 
 	return server.Options{
 		Addr:           fmt.Sprintf(":%d", flags.port),
 		Root:           root,
 		Quiet:          flags.quiet,
-		CertPath:       certPath,
-		KeyPath:        keyPath,
+		CertPath:       certPaths.cert,
+		KeyPath:        certPaths.key,
+		CACertPath:     certPaths.ca,
 		Paths:          flags.nonFlagArgs, // 传递要分享的特定路径
 		ReadTimeout:    readTimeoutDuration,
 		WriteTimeout:   writeTimeoutDuration,
@@ -213,12 +237,62 @@ func buildServerConfig(flags serverFlags, certPath, keyPath string) (server.Opti
 		AuthUser:       flags.authUser,
 		AuthPass:       flags.authPass,
 		AuthRealm:      flags.authRealm,
+		CertWatch:      certWatchDuration,
+		AutoRenewDays:  flags.autoRenew,
+		ClientCAPath:   flags.clientCA,
+		ClientAuth:     flags.clientAuth,
+		OnboardPort:    flags.onboardPort,
+		QRCode:         flags.qr,
+		TLSProfile:     flags.tlsProfile,
+		TLSMinVersion:  flags.tlsMin,
+		TLSCiphers:     flags.tlsCiphers,
+		TLSCurves:      flags.tlsCurves,
+		TLSLog:         flags.tlsLog,
+		VirtualHosts:   vhosts,
+		ACME:           acmeOptions(flags),
+		HTTPPort:       flags.httpPort,
+		HTTP3:          flags.http3,
+		DisableHTTP2:   !flags.http2,
+		H2MaxStreams:   flags.h2MaxStreams,
+		H2MaxFrameSize: flags.h2MaxFrameSize,
+		H2StreamWindow: flags.h2StreamWindow,
+		Upload:         flags.upload,
+		AllowOverwrite: flags.allowOverwrite,
+		UploadStaging:  certgen.GetUploadStagingDir(),
+		UploadMaxSize:  flags.uploadMaxSize,
+		WebDAV:         flags.webdav,
 	}, nil
 }
 
-// getCertificatePaths 获取证书路径
-func getCertificatePaths() (string, string) {
-	return certgen.GetCertPaths()
+// certificatePaths 服务器使用的证书文件路径
+type certificatePaths struct {
+	cert, key, ca string
+	custom        bool // 是否使用 -cert / -key 指定的证书
+}
+
+// getCertificatePaths 获取证书路径，命令行指定的路径优先
+func getCertificatePaths(flags serverFlags) (certificatePaths, error) {
+	var paths certificatePaths
+
+	if flags.certFile != "" || flags.keyFile != "" {
+		if flags.certFile == "" || flags.keyFile == "" {
+			return paths, fmt.Errorf("-cert 与 -key 必须同时指定")
+		}
+		paths.cert, paths.key = flags.certFile, flags.keyFile
+		paths.custom = true
+	} else {
+		paths.cert, paths.key = certgen.GetCertPaths()
+		// 默认 CA 可能已被删除，存在时才用于验证
+		if caPath := certgen.GetCACertPath(); certgen.CheckCertificateExists(caPath) {
+			paths.ca = caPath
+		}
+	}
+
+	if flags.caFile != "" {
+		paths.ca = flags.caFile
+	}
+
+	return paths, nil
 }
 
 // timeoutValues 超时值结构
@@ -243,13 +317,13 @@ func getTimeoutsAndLimits(flags serverFlags) (timeoutValues, sizeLimits) {
 
 	// 返回超时值和大小限制
 	return timeoutValues{
-			readTimeout:  readTimeoutDuration,
-			writeTimeout: writeTimeoutDuration,
-			idleTimeout:  idleTimeoutDuration,
-		}, sizeLimits{
-			maxHeaderBytes: flags.maxHeaderBytes,
-			maxBodyBytes:   flags.maxBodyBytes,
-		}
+		readTimeout:  readTimeoutDuration,
+		writeTimeout: writeTimeoutDuration,
+		idleTimeout:  idleTimeoutDuration,
+	}, sizeLimits{
+		maxHeaderBytes: flags.maxHeaderBytes,
+		maxBodyBytes:   flags.maxBodyBytes,
+	}
 }
 
 // serverFlags 定义服务器选项的结构
@@ -267,6 +341,36 @@ type serverFlags struct {
 	authUser       string
 	authPass       string
 	authRealm      string
+	certWatch      string
+	autoRenew      int
+	certFile       string
+	keyFile        string
+	caFile         string
+	clientCA       string
+	clientAuth     string
+	onboardPort    int
+	qr             bool
+	tlsProfile     string
+	tlsMin         string
+	tlsCiphers     string
+	tlsCurves      string
+	tlsLog         bool
+	vhosts         string
+	acmeDirectory  string
+	acmeDomains    []string
+	acmeEmail      string
+	acmeCA         string
+	acmeHTTPPort   int
+	httpPort       int
+	http3          bool
+	http2          bool
+	h2MaxStreams   int
+	h2MaxFrameSize int
+	h2StreamWindow int
+	upload         bool
+	allowOverwrite bool
+	uploadMaxSize  int64
+	webdav         bool
 	nonFlagArgs    []string
 }
 
@@ -298,20 +402,61 @@ func parseServerFlags(args []string) (serverFlags, error) {
 		authUser:       *flags.authUser,
 		authPass:       *flags.authPass,
 		authRealm:      *flags.authRealm,
+		certWatch:      *flags.certWatch,
+		autoRenew:      *flags.autoRenew,
+		certFile:       *flags.certFile,
+		keyFile:        *flags.keyFile,
+		caFile:         *flags.caFile,
+		clientCA:       *flags.clientCA,
+		clientAuth:     *flags.clientAuth,
+		onboardPort:    *flags.onboardPort,
+		qr:             *flags.qr,
+		tlsProfile:     *flags.tlsProfile,
+		tlsMin:         *flags.tlsMin,
+		tlsCiphers:     *flags.tlsCiphers,
+		tlsCurves:      *flags.tlsCurves,
+		tlsLog:         *flags.tlsLog,
+		vhosts:         *flags.vhosts,
+		acmeDirectory:  *flags.acmeDirectory,
+		acmeDomains:    *flags.acmeDomains,
+		acmeEmail:      *flags.acmeEmail,
+		acmeCA:         *flags.acmeCA,
+		acmeHTTPPort:   *flags.acmeHTTPPort,
+		httpPort:       *flags.httpPort,
+		http3:          *flags.http3,
+		http2:          *flags.http2,
+		h2MaxStreams:   *flags.h2MaxStreams,
+		h2MaxFrameSize: *flags.h2MaxFrameSize,
+		h2StreamWindow: *flags.h2StreamWindow,
+		upload:         *flags.upload,
+		allowOverwrite: *flags.allowOverwrite,
+		uploadMaxSize:  *flags.uploadMaxSize,
+		webdav:         *flags.webdav,
 		nonFlagArgs:    fs.Args(),
 	}, nil
 }
 
 // flagPointers 存储所有标志的指针
 type flagPointers struct {
-	port, maxHeaderBytes *int
-	dir, readTimeout, writeTimeout, idleTimeout, authUser, authPass, authRealm *string
-	quiet, version, help *bool
-	maxBodyBytes *int64
+	port, maxHeaderBytes, autoRenew, onboardPort           *int
+	acmeHTTPPort, httpPort                                 *int
+	h2MaxStreams, h2MaxFrameSize, h2StreamWindow           *int
+	dir, readTimeout, writeTimeout, idleTimeout            *string
+	authUser, authPass, authRealm, certWatch               *string
+	certFile, keyFile, caFile, clientCA, clientAuth        *string
+	tlsProfile, tlsMin, tlsCiphers, tlsCurves, vhosts      *string
+	acmeDirectory, acmeEmail, acmeCA                       *string
+	quiet, version, help, qr, tlsLog, http3, http2, upload *bool
+	allowOverwrite, webdav                                 *bool
+	maxBodyBytes, uploadMaxSize                            *int64
+	acmeDomains                                            *stringListFlag
 }
 
 // defineFlags 定义命令行标志
 func defineFlags(fs *flag.FlagSet) flagPointers {
+	acmeDomains := &stringListFlag{}
+	fs.Var(acmeDomains, "acme-domains", "通过 ACME 申请证书的主机名（逗号分隔，可重复）")
+
 	return flagPointers{
 		port:           fs.Int("port", 8443, "监听端口（默认 8443）"),
 		dir:            fs.String("dir", "", "共享目录"),
@@ -326,6 +471,57 @@ func defineFlags(fs *flag.FlagSet) flagPointers {
 		authUser:       fs.String("auth-user", "", "基本身份验证用户名"),
 		authPass:       fs.String("auth-pass", "", "基本身份验证密码"),
 		authRealm:      fs.String("auth-realm", "hserve-secure-area", "身份验证领域"),
+		certWatch:      fs.String("cert-watch", server.DefaultCertWatchInterval.String(), "证书文件检查间隔，文件变化时热加载（0 表示不检查）"),
+		autoRenew:      fs.Int("auto-renew", 0, "证书剩余天数少于该值时使用已存储的 CA 自动续期（0 表示不续期）"),
+		certFile:       fs.String("cert", "", "服务器证书文件（PEM，可附带中间证书），需与 -key 同时使用"),
+		keyFile:        fs.String("key", "", "服务器私钥文件（PEM）"),
+		caFile:         fs.String("ca", "", "用于验证服务器证书的 CA 证书文件"),
+		clientCA:       fs.String("client-ca", "", "用于验证客户端证书的 CA 证书文件（启用双向 TLS）"),
+		clientAuth:     fs.String("client-auth", "", "客户端证书验证模式：require|verify-if-given（默认 require）"),
+		onboardPort:    fs.Int("onboard-port", 0, "在该端口通过 HTTP 提供 CA 证书下载页（0 表示不启用）"),
+		qr:             fs.Bool("qr", true, "启动时在终端显示访问地址二维码（-qr=false 关闭）"),
+		tlsProfile:     fs.String("tls-profile", "intermediate", "TLS 策略：modern|intermediate|compat"),
+		tlsMin:         fs.String("tls-min", "", "覆盖策略的最低 TLS 版本：1.0|1.1|1.2|1.3"),
+		tlsCiphers:     fs.String("tls-ciphers", "", "覆盖策略的密码套件（逗号分隔，仅作用于 TLS 1.2 及以下）"),
+		tlsCurves:      fs.String("tls-curves", "", "覆盖策略的曲线偏好（逗号分隔，例如 X25519,P256）"),
+		tlsLog:         fs.Bool("tls-log", false, "访问日志中附带 TLS 版本、密码套件、ALPN、SNI 与会话复用信息"),
+		vhosts:         fs.String("vhosts", "", "虚拟主机配置文件（JSON），按主机名使用不同证书与共享目录"),
+		acmeDirectory:  fs.String("acme-directory", "", "ACME 目录地址，设置后为 -acme-domains 中的主机名自动申请证书"),
+		acmeDomains:    acmeDomains,
+		acmeEmail:      fs.String("acme-email", "", "ACME 账户联系邮箱"),
+		acmeCA:         fs.String("acme-ca", "", "额外信任的 ACME 服务器 CA 证书（例如 Pebble 的 minica）"),
+		acmeHTTPPort:   fs.Int("acme-http-port", 0, "HTTP-01 验证端口（0 表示只使用 TLS-ALPN-01）"),
+		httpPort:       fs.Int("http-port", 0, "在该端口监听明文 HTTP 并重定向到 HTTPS（0 表示不启用）"),
+		http3:          fs.Bool("http3", false, "在同一端口号的 UDP 上提供 HTTP/3（QUIC）"),
+		http2:          fs.Bool("http2", true, "启用 HTTP/2（-http2=false 只使用 HTTP/1.1）"),
+		h2MaxStreams:   fs.Int("h2-max-streams", 0, "HTTP/2 每个连接的最大并发流数（0 表示默认 250）"),
+		h2MaxFrameSize: fs.Int("h2-max-frame-size", 0, "HTTP/2 可接收的最大帧大小（字节，16384-16777215，0 表示默认 1MB）"),
+		h2StreamWindow: fs.Int("h2-stream-window", 0, "HTTP/2 每个流的接收窗口（字节，小于 4MB，0 表示默认 1MB）"),
+		upload:         fs.Bool("upload", false, "允许通过浏览器表单或 HTTP PUT 上传文件"),
+		allowOverwrite: fs.Bool("upload-overwrite", false, "允许上传覆盖已有文件"),
+		uploadMaxSize:  fs.Int64("upload-max-size", 0, "可续传上传（tus）的最大文件大小（字节，0 表示不限制）"),
+		webdav:         fs.Bool("webdav", false, "通过 WebDAV 提供共享目录，修改文件还需要 -upload"),
+	}
+}
+
+// acmeOptions 根据命令行参数生成 ACME 选项，未设置 -acme-directory 时返回 nil
+func acmeOptions(flags serverFlags) *server.ACMEOptions {
+	if flags.acmeDirectory == "" {
+		return nil
+	}
+
+	var domains []string
+	for _, d := range flags.acmeDomains {
+		domains = append(domains, strings.TrimSuffix(strings.ToLower(d), "."))
+	}
+
+	return &server.ACMEOptions{
+		DirectoryURL: flags.acmeDirectory,
+		Domains:      domains,
+		Email:        flags.acmeEmail,
+		CACertPath:   flags.acmeCA,
+		HTTPPort:     flags.acmeHTTPPort,
+		CacheDir:     certgen.GetACMECacheDir(),
 	}
 }
 
@@ -387,6 +583,67 @@ func showServerHelp() {
 	fmt.Println("      基本身份验证密码")
 	fmt.Println("  -auth-realm string")
 	fmt.Println("      身份验证领域（默认 \"hserve-secure-area\"")
+	fmt.Println("  -cert-watch string")
+	fmt.Println("      证书文件检查间隔，文件变化时热加载（默认 30s，0 表示不检查）")
+	fmt.Println("  -auto-renew int")
+	fmt.Println("      证书剩余天数少于该值时使用已存储的 CA 自动续期（默认 0，不续期）")
+	fmt.Println("  -cert string")
+	fmt.Println("      使用自带的服务器证书（PEM，可附带中间证书），需与 -key 同时使用")
+	fmt.Println("  -key string")
+	fmt.Println("      自带证书对应的私钥文件")
+	fmt.Println("  -ca string")
+	fmt.Println("      启动前用于验证服务器证书的 CA 证书文件")
+	fmt.Println("  -client-ca string")
+	fmt.Println("      用于验证客户端证书的 CA 证书文件，启用双向 TLS")
+	fmt.Println("  -client-auth string")
+	fmt.Println("      客户端证书验证模式：require|verify-if-given（默认 require）")
+	fmt.Println("  -qr")
+	fmt.Println("      启动时在终端显示局域网访问地址二维码（默认开启，-qr=false 关闭，-quiet 时不显示）")
+	fmt.Println("  -onboard-port int")
+	fmt.Println("      在该端口通过 HTTP 提供 CA 证书下载页，并在终端显示二维码（默认 0，不启用）")
+	fmt.Println("  -tls-profile string")
+	fmt.Println("      TLS 策略：modern（仅 TLS 1.3）|intermediate（默认，TLS 1.2+）|compat（TLS 1.0+，兼容旧设备）")
+	fmt.Println("  -tls-min string")
+	fmt.Println("      覆盖策略的最低 TLS 版本：1.0|1.1|1.2|1.3")
+	fmt.Println("  -tls-ciphers string")
+	fmt.Println("      覆盖策略的密码套件，逗号分隔（仅作用于 TLS 1.2 及以下）")
+	fmt.Println("  -tls-curves string")
+	fmt.Println("      覆盖策略的曲线偏好，逗号分隔：X25519MLKEM768|X25519|P256|P384|P521")
+	fmt.Println("  -tls-log")
+	fmt.Println("      访问日志中附带 TLS 版本、密码套件、ALPN、SNI 与会话复用信息")
+	fmt.Println("  -vhosts string")
+	fmt.Println("      虚拟主机配置文件（JSON），按 SNI 选择证书、按 Host 选择共享目录")
+	fmt.Println("  -acme-directory string")
+	fmt.Println("      ACME 目录地址，设置后为 -acme-domains 中的主机名自动申请并续期证书")
+	fmt.Println("  -acme-domains string")
+	fmt.Println("      通过 ACME 申请证书的主机名（逗号分隔，可重复）")
+	fmt.Println("  -acme-email string")
+	fmt.Println("      ACME 账户联系邮箱")
+	fmt.Println("  -acme-ca string")
+	fmt.Println("      额外信任的 ACME 服务器 CA 证书（内网 ACME 服务器 / Pebble）")
+	fmt.Println("  -acme-http-port int")
+	fmt.Println("      HTTP-01 验证端口（默认 0，只使用 TLS-ALPN-01）")
+	fmt.Println("  -http-port int")
+	fmt.Println("      在该端口监听明文 HTTP 并 308 重定向到 HTTPS（默认 0，不启用）")
+	fmt.Println("  -http3")
+	fmt.Println("      在同一端口号的 UDP 上提供 HTTP/3（QUIC），通过 Alt-Svc 通告给浏览器")
+	fmt.Println("  -http2")
+	fmt.Println("      启用 HTTP/2（默认 true，-http2=false 只使用 HTTP/1.1）")
+	fmt.Println("  -h2-max-streams int")
+	fmt.Println("      HTTP/2 每个连接的最大并发流数（默认 250）")
+	fmt.Println("  -h2-max-frame-size int")
+	fmt.Println("      HTTP/2 可接收的最大帧大小，16384-16777215 字节（默认 1MB）")
+	fmt.Println("  -h2-stream-window int")
+	fmt.Println("      HTTP/2 每个流的接收窗口，影响单个上传的速度（默认 1MB，上限 4MB）")
+	fmt.Println("  -upload")
+	fmt.Println("      允许通过目录页面的表单或 HTTP PUT 上传文件（受 -max-body-bytes 限制）")
+	fmt.Println("  -upload-overwrite")
+	fmt.Println("      允许上传覆盖已有文件（默认拒绝，返回 409）")
+	fmt.Println("  -upload-max-size int")
+	fmt.Println("      可续传上传（tus 协议）的最大文件大小，字节（默认 0，不限制；不受 -max-body-bytes 限制）")
+	fmt.Println("  -webdav")
+	fmt.Println("      通过 WebDAV 提供共享目录，可在文件管理器中挂载（默认只读；加 -upload 允许新建、复制、移动，")
+	fmt.Println("      再加 -upload-overwrite 允许删除与覆盖）")
 	fmt.Println("  -version")
 	fmt.Println("      显示版本信息")
 	fmt.Println("  -help")
@@ -400,10 +657,27 @@ func showServerHelp() {
 	fmt.Println("  hserve /file1 /file2      # 分享多个文件")
 	fmt.Println("  hserve -port 9999 -read-timeout 60s -max-body-bytes 20971520 -dir /path/to/files")
 	fmt.Println("  hserve -auth-user admin -auth-pass 123456 /path/to/secure/dir")
+	fmt.Println("  hserve -auto-renew 30          # 长期运行时自动续期证书")
+	fmt.Println("  hserve -cert dev.pem -key dev-key.pem -ca rootCA.pem   # 使用 mkcert 等工具签发的证书")
+	fmt.Println("  hserve -client-ca ~/hserve-ca.crt -client-auth require # 仅允许持有客户端证书的设备访问")
+	fmt.Println("  hserve -onboard-port 8080      # 手机扫码安装 CA 证书")
+	fmt.Println("  hserve -tls-profile compat     # 兼容 Android 4.x 等旧设备")
+	fmt.Println("  hserve -port 443 -vhosts sites.json   # 一个进程服务多个站点")
+	fmt.Println("  hserve -port 443 -http-port 80  # http:// 自动跳转到 https://")
+	fmt.Println("  hserve -upload -max-body-bytes 4294967296 -auth-user me -auth-pass 密码   # 手机上传照片")
+	fmt.Println("  hserve -webdav -upload -upload-overwrite -auth-user me -auth-pass 密码   # 在文件管理器中挂载")
+	fmt.Println("  hserve -http2=false            # 兼容不能正确处理 HTTP/2 的客户端")
+	fmt.Println("  hserve -http3                  # 不稳定的 Wi-Fi 下传输大文件")
+	fmt.Println("  hserve -port 443 -acme-directory https://ca.internal/acme/directory -acme-domains files.internal")
 }
 
 // runCertGen 执行证书生成命令
 func runCertGen(args []string) {
+	// 处理证书子命令
+	if len(args) > 0 && handleCertSubcommand(args[0], args[1:]) {
+		return
+	}
+
 	// 解析参数
 	opts, err := parseCertGenOptions(args)
 	if err != nil {
@@ -423,17 +697,46 @@ func runCertGen(args []string) {
 // certGenOptions 证书生成选项
 type certGenOptions struct {
 	force   bool
+	newCA   bool
+	sans    []string
+	autoSAN bool
+	keyType certgen.KeyType
+	days    int
 	version bool
 	help    bool
 	args    []string
 }
 
+// stringListFlag 可重复、支持逗号分隔的字符串列表参数
+type stringListFlag []string
+
+// String 实现 flag.Value 接口
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set 实现 flag.Value 接口，每次出现都会追加
+func (s *stringListFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*s = append(*s, item)
+		}
+	}
+	return nil
+}
+
 // parseCertGenOptions 解析证书生成选项
 func parseCertGenOptions(args []string) (certGenOptions, error) {
 	// 创建新的 FlagSet 来解析参数，避免与全局 flag.CommandLine 冲突
 	certFlags := flag.NewFlagSet("certgen", flag.ExitOnError)
 
-	force := certFlags.Bool("force", false, "强制重新生成证书")
+	force := certFlags.Bool("force", false, "强制重新签发服务器证书（沿用已有 CA）")
+	newCA := certFlags.Bool("new-ca", false, "同时重新生成 CA（需在设备上重新安装）")
+	var sans stringListFlag
+	certFlags.Var(&sans, "san", "额外的证书名称（IP / 域名，逗号分隔，可重复）")
+	autoSAN := certFlags.Bool("auto-san", false, "自动加入本机网卡地址与主机名")
+	keyType := certFlags.String("key-type", string(certgen.DefaultKeyType), "私钥类型：rsa2048|rsa4096|ecdsa-p256|ecdsa-p384|ed25519")
+	days := certFlags.Int("days", certgen.DefaultLeafDays, "服务器证书有效天数")
 	version := certFlags.Bool("version", false, "显示版本信息")
 	help := certFlags.Bool("help", false, "显示此帮助信息")
 
@@ -442,8 +745,18 @@ func parseCertGenOptions(args []string) (certGenOptions, error) {
 		return certGenOptions{}, err
 	}
 
+	kt, err := certgen.ParseKeyType(*keyType)
+	if err != nil {
+		return certGenOptions{}, err
+	}
+
 	return certGenOptions{
 		force:   *force,
+		newCA:   *newCA,
+		sans:    sans,
+		autoSAN: *autoSAN,
+		keyType: kt,
+		days:    *days,
 		version: *version,
 		help:    *help,
 		args:    certFlags.Args(),
@@ -470,20 +783,220 @@ func executeCertGeneration(opts certGenOptions) {
 	fmt.Println("🔐 HTTPS 证书生成工具 - 为您的安全访问保驾护航")
 	fmt.Println("🌟 正在为您生成安全证书，请稍候...")
 
-	if err := certgen.Generate(opts.force); err != nil {
+	genOpts := certgen.Options{
+		Force:   opts.force,
+		NewCA:   opts.newCA,
+		SANs:    opts.sans,
+		AutoSAN: opts.autoSAN,
+		KeyType: opts.keyType,
+		Days:    opts.days,
+	}
+
+	if err := certgen.Generate(genOpts); err != nil {
 		fatal("证书生成失败", err)
 	}
 
 	fmt.Println("================================")
 }
 
+// handleCertSubcommand 处理证书子命令，未识别时返回 false
+func handleCertSubcommand(name string, args []string) bool {
+	switch strings.ToLower(name) {
+	case "inspect":
+		runCertInspect(args)
+	case "renew":
+		runCertRenew(args)
+	case "client":
+		runCertClient(args)
+	case "export":
+		runCertExport(args)
+	default:
+		return false
+	}
+	return true
+}
+
+// runCertInspect 显示证书详情与健康状态
+func runCertInspect(args []string) {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+
+	defaultCert, defaultKey := certgen.GetCertPaths()
+	certPath := fs.String("cert", defaultCert, "服务器证书路径")
+	keyPath := fs.String("key", defaultKey, "服务器私钥路径")
+	caPath := fs.String("ca", certgen.GetCACertPath(), "CA 证书路径")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+
+	if err := fs.Parse(args); err != nil {
+		fatal("解析证书检查参数失败", err)
+		return
+	}
+
+	report := certgen.Inspect(*certPath, *keyPath, *caPath)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fatal("输出 JSON 失败", err)
+		}
+	} else {
+		certgen.PrintReport(report)
+	}
+
+	if !report.Healthy() {
+		exitProgram()
+	}
+}
+
+// runCertRenew 使用已存储的 CA 续期服务器证书
+func runCertRenew(args []string) {
+	fs := flag.NewFlagSet("renew", flag.ExitOnError)
+
+	force := fs.Bool("force", false, "无论剩余有效期多少都重新签发")
+	within := fs.Int("within", certgen.DefaultRenewWithinDays, "剩余天数少于该值时续期")
+	days := fs.Int("days", certgen.DefaultLeafDays, "新证书有效天数")
+
+	if err := fs.Parse(args); err != nil {
+		fatal("解析证书续期参数失败", err)
+		return
+	}
+
+	renewed, err := certgen.Renew(certgen.RenewOptions{
+		Force:  *force,
+		Within: *within,
+		Days:   *days,
+	})
+	if err != nil {
+		fatal("证书续期失败", err)
+		return
+	}
+
+	if renewed {
+		fmt.Println("✅ 服务器证书已续期，设备上已安装的 CA 无需重新安装")
+	} else {
+		fmt.Printf("✅ 服务器证书剩余有效期超过 %d 天，无需续期\n", *within)
+	}
+}
+
+// runCertClient 使用已存储的 CA 签发客户端证书
+func runCertClient(args []string) {
+	fs := flag.NewFlagSet("client", flag.ExitOnError)
+
+	name := fs.String("name", "", "客户端名称（写入证书 CN，并用作文件名）")
+	days := fs.Int("days", certgen.DefaultLeafDays, "证书有效天数")
+	keyType := fs.String("key-type", string(certgen.KeyTypeECDSAP256), "私钥类型：rsa2048|rsa4096|ecdsa-p256|ecdsa-p384|ed25519")
+	password := fs.String("password", "", "PKCS#12 文件密码（默认随机生成）")
+	outDir := fs.String("out", certgen.GetClientCertDir(), "输出目录")
+
+	if err := fs.Parse(args); err != nil {
+		fatal("解析客户端证书参数失败", err)
+		return
+	}
+
+	kt, err := certgen.ParseKeyType(*keyType)
+	if err != nil {
+		fatal("解析客户端证书参数失败", err)
+		return
+	}
+
+	files, err := certgen.IssueClientCertificate(certgen.ClientOptions{
+		Name:     *name,
+		Days:     *days,
+		KeyType:  kt,
+		Password: *password,
+		OutDir:   *outDir,
+	})
+	if err != nil {
+		fatal("签发客户端证书失败", err)
+		return
+	}
+
+	fmt.Println("✅ 客户端证书签发完成")
+	fmt.Println("📄 证书:", files.CertPath)
+	fmt.Println("🔑 私钥:", files.KeyPath)
+	fmt.Println("📦 PKCS#12:", files.P12Path)
+	if *password == "" {
+		fmt.Println("🔒 PKCS#12 密码:", files.Password)
+		fmt.Println("   密码只显示一次，请妥善保存")
+	}
+	fmt.Println()
+	fmt.Println("📱 将 .p12 文件复制到手机，设置 → 安全 → 加密与凭据 → 安装证书 → VPN 和应用用户证书")
+	fmt.Println("🚀 启动服务器时启用验证: hserve -client-ca", certgen.GetCACertPath())
+}
+
+// runCertExport 以系统可识别的格式导出 CA 或服务器证书
+func runCertExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+
+	kind := fs.String("kind", "ca", "导出的证书：ca|server")
+	format := fs.String("format", "der", "导出格式：pem|der|p12")
+	out := fs.String("out", "", "输出文件（默认与 CA 证书同目录）")
+	password := fs.String("password", "", "p12 文件密码（默认随机生成）")
+
+	if err := fs.Parse(args); err != nil {
+		fatal("解析证书导出参数失败", err)
+		return
+	}
+
+	k, err := certgen.ParseExportKind(*kind)
+	if err != nil {
+		fatal("解析证书导出参数失败", err)
+		return
+	}
+	f, err := certgen.ParseExportFormat(*format)
+	if err != nil {
+		fatal("解析证书导出参数失败", err)
+		return
+	}
+
+	result, err := certgen.Export(certgen.ExportOptions{
+		Kind:     k,
+		Format:   f,
+		OutPath:  *out,
+		Password: *password,
+	})
+	if err != nil {
+		fatal("证书导出失败", err)
+		return
+	}
+
+	fmt.Println("✅ 证书已导出:", result.Path)
+	if f == certgen.ExportP12 && *password == "" {
+		fmt.Println("🔒 p12 密码:", result.Password)
+	}
+	if k == certgen.ExportServer && f == certgen.ExportP12 {
+		fmt.Println("⚠️  该文件包含服务器私钥，请勿分发")
+	}
+}
+
 // showCertHelp 显示证书生成帮助信息
 func showCertHelp() {
 	fmt.Println("🔐 hserve cert - 生成 HTTPS 证书")
 	fmt.Println()
+	fmt.Println("🧰 子命令:")
+	fmt.Println("  inspect [-json] [-cert 文件] [-key 文件] [-ca 文件]")
+	fmt.Println("      显示证书主题、名称、有效期、指纹、密钥类型并验证证书链")
+	fmt.Println("  renew [-within 30] [-days 397] [-force]")
+	fmt.Println("      临近过期时使用已存储的 CA 续期服务器证书（保留名称与密钥类型）")
+	fmt.Println("  client -name 名称 [-days 397] [-key-type ecdsa-p256] [-password 密码] [-out 目录]")
+	fmt.Println("      使用已存储的 CA 签发客户端证书，并生成可导入手机 / 浏览器的 .p12 文件")
+	fmt.Println("  export [-kind ca|server] [-format pem|der|p12] [-out 文件] [-password 密码]")
+	fmt.Println("      导出 CA（默认）或服务器证书，der 适用于 Windows / Android，p12 适用于 macOS")
+	fmt.Println()
 	fmt.Println("✨ 可用选项:")
 	fmt.Println("  -force")
-	fmt.Println("      强制重新生成证书")
+	fmt.Println("      强制重新签发服务器证书（沿用已有 CA，无需在设备上重新安装）")
+	fmt.Println("  -new-ca")
+	fmt.Println("      同时重新生成 CA（旧 CA 失效，需在设备上重新安装）")
+	fmt.Println("  -san string")
+	fmt.Println("      额外的证书名称，IP 或域名，逗号分隔，可重复（如 192.168.1.20,devbox.lan,*.devbox.lan）")
+	fmt.Println("  -auto-san")
+	fmt.Println("      自动加入本机网卡地址与主机名（手机通过 Wi-Fi 访问时推荐）")
+	fmt.Println("  -key-type string")
+	fmt.Println("      私钥类型：rsa2048|rsa4096|ecdsa-p256|ecdsa-p384|ed25519（默认 rsa2048）")
+	fmt.Println("      沿用已有 CA 时仅作用于服务器证书；多数浏览器尚不支持 ed25519 证书")
+	fmt.Println("  -days int")
+	fmt.Println("      服务器证书有效天数（默认 397，超过 398 天会被 Apple / Chrome 拒绝）")
 	fmt.Println("  -version")
 	fmt.Println("      显示版本信息")
 	fmt.Println("  -help")
@@ -492,4 +1005,13 @@ func showCertHelp() {
 	fmt.Println("💡 使用示例:")
 	fmt.Println("  hserve cert")
 	fmt.Println("  hserve cert -force")
+	fmt.Println("  hserve cert -force -new-ca")
+	fmt.Println("  hserve cert -san 192.168.1.20,devbox.lan -san '*.devbox.lan'")
+	fmt.Println("  hserve cert -force -auto-san")
+	fmt.Println("  hserve cert -force -key-type ecdsa-p256")
+	fmt.Println("  hserve cert inspect")
+	fmt.Println("  hserve cert inspect -json")
+	fmt.Println("  hserve cert renew")
+	fmt.Println("  hserve cert client -name alice")
+	fmt.Println("  hserve cert export -format der -out /sdcard/Download/hserve-ca.cer")
 }
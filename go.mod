@@ -1,3 +1,16 @@
 module github.com/Alhkxsj/hserve
 
-go 1.21
+go 1.24
+
+require (
+	github.com/quic-go/quic-go v0.59.1
+	golang.org/x/crypto v0.41.0
+	golang.org/x/net v0.43.0
+	software.sslmate.com/src/go-pkcs12 v0.5.0
+)
+
+require (
+	github.com/quic-go/qpack v0.6.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+)
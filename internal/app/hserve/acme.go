@@ -0,0 +1,261 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEOptions 通过 ACME 自动申请证书的选项
+type ACMEOptions struct {
+	DirectoryURL string   // ACME 目录地址，例如 https://ca.internal/acme/directory
+	Domains      []string // 申请证书的主机名，只有这些名称会使用 ACME 证书
+	Email        string   // 账户联系邮箱，可为空
+	CACertPath   string   // 额外信任的 ACME 服务器 CA（例如 Pebble 的 minica），为空时只使用系统 CA
+	HTTPPort     int      // HTTP-01 验证端口，0 表示只使用 TLS-ALPN-01
+	CacheDir     string   // 账户私钥与证书缓存目录
+}
+
+// acmeIssuer 通过 ACME 申请并自动续期证书
+type acmeIssuer struct {
+	opt     ACMEOptions
+	manager *autocert.Manager
+	httpSrv *http.Server // HTTP-01 验证服务器
+}
+
+// newACMEIssuer 创建 ACME 证书管理器
+func newACMEIssuer(opt ACMEOptions) (*acmeIssuer, error) {
+	if len(opt.Domains) == 0 {
+		return nil, fmt.Errorf("启用 ACME 需要使用 -acme-domains 指定主机名")
+	}
+
+	httpClient, err := acmeHTTPClient(opt.CACertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(opt.CacheDir, 0700); err != nil {
+		return nil, fmt.Errorf("创建 ACME 缓存目录失败: %w", err)
+	}
+
+	return &acmeIssuer{
+		opt: opt,
+		manager: &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(opt.CacheDir),
+			HostPolicy: autocert.HostWhitelist(opt.Domains...),
+			Email:      opt.Email,
+			Client: &acme.Client{
+				DirectoryURL: opt.DirectoryURL,
+				HTTPClient:   httpClient,
+			},
+		},
+	}, nil
+}
+
+// acmeHTTPClient 创建访问 ACME 服务器的 HTTP 客户端，可额外信任指定 CA
+func acmeHTTPClient(caCertPath string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if caCertPath != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取 ACME 服务器 CA 失败: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s 中没有有效的 PEM 证书", caCertPath)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &http.Client{
+		Transport: &orderLocationTransport{base: transport, orders: make(map[string]string)},
+		Timeout:   30 * time.Second,
+	}, nil
+}
+
+// orderLocationTransport 为 finalize 响应补充订单地址
+//
+// Pebble 异步签发证书：finalize 返回 200 与 "status": "processing"，但不带 Location 头
+// （RFC 8555 7.4 只要求 newOrder 响应携带 Location）。acme.Client.CreateOrderCert 在订单未完成时
+// 用 finalize 响应的 Location 作为 WaitOrder 的轮询地址，地址为空时申请失败。
+// 这里记录 newOrder 响应中 finalize 地址与订单地址的对应关系，在 finalize 响应缺少 Location 时补上；
+// 为了读取 finalize 地址，带 Location 的 JSON 响应体会先读入内存（订单对象只有几百字节）。
+type orderLocationTransport struct {
+	base   http.RoundTripper
+	mu     sync.Mutex
+	orders map[string]string // finalize 地址 → 订单地址
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *orderLocationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if err != nil || req.Method != http.MethodPost {
+		return res, err
+	}
+
+	location := res.Header.Get("Location")
+	if location == "" {
+		t.mu.Lock()
+		orderURL, ok := t.orders[req.URL.String()]
+		t.mu.Unlock()
+		if ok {
+			res.Header.Set("Location", orderURL)
+		}
+		return res, nil
+	}
+
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		return res, nil
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	var order struct {
+		Finalize string `json:"finalize"`
+	}
+	if json.Unmarshal(body, &order) == nil && order.Finalize != "" {
+		if u, err := req.URL.Parse(location); err == nil {
+			location = u.String()
+		}
+		t.mu.Lock()
+		t.orders[order.Finalize] = location
+		t.mu.Unlock()
+	}
+	return res, nil
+}
+
+// install 将 ACME 证书接入 TLS 配置
+//
+// 握手请求 ACME 主机名或携带 acme-tls/1 时由 ACME 处理，其他主机名交给原有的证书选择逻辑。
+func (a *acmeIssuer) install(config *tls.Config) {
+	fallback := config.GetCertificate
+	config.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if a.handles(hello.ServerName) || isACMEChallengeHello(hello) {
+			return a.manager.GetCertificate(hello)
+		}
+		return fallback(hello)
+	}
+	config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+}
+
+// handles 检查主机名是否由 ACME 提供证书
+func (a *acmeIssuer) handles(serverName string) bool {
+	serverName = strings.TrimSuffix(strings.ToLower(serverName), ".")
+	for _, d := range a.opt.Domains {
+		if d == serverName {
+			return true
+		}
+	}
+	return false
+}
+
+// isACMEChallengeHello 检查是否为 TLS-ALPN-01 验证握手
+func isACMEChallengeHello(hello *tls.ClientHelloInfo) bool {
+	return len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acme.ALPNProto
+}
+
+// start 启动 HTTP-01 验证服务器
+//
+// HTTP-01 端口与 -http-port 相同时由重定向服务器通过 httpHandler 处理验证，不再单独监听。
+// 预先申请证书由 prefetch 完成，需在 HTTPS 监听建立后调用，否则 TLS-ALPN-01 验证无人应答。
+func (a *acmeIssuer) start(listenHost string, redirectPort int) error {
+	if a.opt.HTTPPort > 0 && a.opt.HTTPPort != redirectPort {
+		addr := net.JoinHostPort(listenHost, strconv.Itoa(a.opt.HTTPPort))
+		ln, err := createListener(addr)
+		if err != nil {
+			return formatPortError(addr, err)
+		}
+
+		a.httpSrv = &http.Server{
+			Handler:           a.manager.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := a.httpSrv.Serve(ln); err != nil && err != http.ErrServerClosed {
+				fmt.Println("❌ ACME HTTP-01 验证服务器已停止:", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// httpHandler 返回处理 HTTP-01 验证请求的处理器，其他请求交给 fallback
+func (a *acmeIssuer) httpHandler(fallback http.Handler) http.Handler {
+	return a.manager.HTTPHandler(fallback)
+}
+
+// prefetch 为每个主机名申请（或从缓存加载）证书
+//
+// 启动时申请证书可以尽早暴露配置问题，否则要等到第一个客户端连接。
+func (a *acmeIssuer) prefetch(quiet bool) {
+	for _, domain := range a.opt.Domains {
+		cert, err := a.manager.GetCertificate(prefetchHello(domain))
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] ❌ ACME 申请 %s 的证书失败: %v\n",
+				time.Now().Format("15:04:05"), domain, err)
+			continue
+		}
+		if !quiet && cert.Leaf != nil {
+			fmt.Printf("[%s] ✅ 已获取 %s 的 ACME 证书，有效期至 %s\n",
+				time.Now().Format("15:04:05"), domain, cert.Leaf.NotAfter.Format("2006-01-02"))
+		}
+	}
+}
+
+// prefetchHello 构造申请 ECDSA 证书所需的握手信息
+func prefetchHello(domain string) *tls.ClientHelloInfo {
+	return &tls.ClientHelloInfo{
+		ServerName:        domain,
+		CipherSuites:      []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedCurves:   []tls.CurveID{tls.CurveP256},
+		SupportedVersions: []uint16{tls.VersionTLS13, tls.VersionTLS12},
+	}
+}
+
+// stop 关闭 HTTP-01 验证服务器
+func (a *acmeIssuer) stop() {
+	if a.httpSrv != nil {
+		_ = a.httpSrv.Close()
+	}
+}
+
+// printACME 输出 ACME 配置信息
+//
+// 未开启 HTTP-01 且监听端口不是 443 时提示 TLS-ALPN-01 验证无法完成。
+func printACME(opt *ACMEOptions, addr string) {
+	fmt.Printf("🔑 ACME 证书: %s (%s)\n", strings.Join(opt.Domains, ", "), opt.DirectoryURL)
+	if opt.HTTPPort > 0 {
+		fmt.Printf("   验证方式: HTTP-01（端口 %d）与 TLS-ALPN-01\n", opt.HTTPPort)
+	} else {
+		fmt.Println("   验证方式: TLS-ALPN-01")
+		if _, port, _ := net.SplitHostPort(addr); port != "443" {
+			fmt.Printf("⚠️  TLS-ALPN-01 需要 ACME 服务器能连接到 443 端口，当前监听 %s；请使用 -port 443 或设置 -acme-http-port 开启 HTTP-01\n", port)
+		}
+	}
+	fmt.Printf("   缓存目录: %s\n", opt.CacheDir)
+}
@@ -0,0 +1,562 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// acmeStub 模拟 Pebble 行为的最小 ACME 服务器
+//
+// 真实执行 HTTP-01 / TLS-ALPN-01 验证：连接 validateAddr 并检查密钥授权；
+// finalize 与 Pebble 一样返回 processing 且不带 Location。不校验 JWS 签名。
+type acmeStub struct {
+	t             *testing.T
+	srv           *httptest.Server
+	challengeType string // 提供的验证方式
+	validateAddr  string // 验证时连接的地址，代替 DNS 解析
+
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+
+	mu         sync.Mutex
+	thumbprint string
+	nonce      int
+	orders     []*stubOrder
+}
+
+// stubOrder 订单及其唯一的授权
+type stubOrder struct {
+	domain      string
+	status      string
+	authzStatus string
+	token       string
+	certPEM     []byte
+}
+
+// newACMEStub 启动 ACME 服务器，返回目录地址与其 TLS 证书的 PEM 文件
+func newACMEStub(t *testing.T, challengeType string) (*acmeStub, string) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "stub ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(der)
+
+	s := &acmeStub{t: t, challengeType: challengeType, caKey: caKey, caCert: caCert}
+	s.srv = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.srv.Close)
+
+	caFile := filepath.Join(t.TempDir(), "acme-ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return s, caFile
+}
+
+// orderCount 返回已创建的订单数
+func (s *acmeStub) orderCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.orders)
+}
+
+// serveHTTP 分发 ACME 请求
+func (s *acmeStub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.nonce++
+	w.Header().Set("Replay-Nonce", "nonce-"+strconv.Itoa(s.nonce))
+	s.mu.Unlock()
+
+	if r.URL.Path == "/dir" {
+		writeStubJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   s.srv.URL + "/nonce",
+			"newAccount": s.srv.URL + "/account",
+			"newOrder":   s.srv.URL + "/order",
+		})
+		return
+	}
+	if r.URL.Path == "/nonce" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	header, payload, err := parseStubJWS(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kind, idText, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kind == "account" {
+		s.thumbprint, err = stubThumbprint(header.JWK)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", s.srv.URL+"/account/1")
+		writeStubJSON(w, http.StatusCreated, map[string]string{"status": "valid"})
+		return
+	}
+	if kind == "order" && idText == "" {
+		var req struct {
+			Identifiers []struct{ Value string }
+		}
+		if err := json.Unmarshal(payload, &req); err != nil || len(req.Identifiers) != 1 {
+			http.Error(w, "bad order", http.StatusBadRequest)
+			return
+		}
+		s.orders = append(s.orders, &stubOrder{
+			domain:      req.Identifiers[0].Value,
+			status:      "pending",
+			authzStatus: "pending",
+			token:       "token" + strconv.Itoa(len(s.orders)),
+		})
+		id := len(s.orders) - 1
+		w.Header().Set("Location", s.orderURL(id))
+		writeStubJSON(w, http.StatusCreated, s.orderJSON(id))
+		return
+	}
+
+	id, err := strconv.Atoi(idText)
+	if err != nil || id < 0 || id >= len(s.orders) {
+		http.NotFound(w, r)
+		return
+	}
+	o := s.orders[id]
+	switch kind {
+	case "order":
+		writeStubJSON(w, http.StatusOK, s.orderJSON(id))
+	case "authz":
+		writeStubJSON(w, http.StatusOK, s.authzJSON(id))
+	case "chal":
+		if s.validate(o) {
+			o.authzStatus = "valid"
+			o.status = "ready"
+		} else {
+			o.authzStatus = "invalid"
+			o.status = "invalid"
+		}
+		writeStubJSON(w, http.StatusOK, s.challengeJSON(id))
+	case "finalize":
+		var req struct{ CSR string }
+		_ = json.Unmarshal(payload, &req)
+		if err := s.issue(o, req.CSR); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// 与 Pebble 相同：返回 processing，不带 Location
+		body := s.orderJSON(id)
+		body["status"] = "processing"
+		delete(body, "certificate")
+		writeStubJSON(w, http.StatusOK, body)
+	case "cert":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(o.certPEM)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// orderURL 返回订单地址
+func (s *acmeStub) orderURL(id int) string {
+	return fmt.Sprintf("%s/order/%d", s.srv.URL, id)
+}
+
+// orderJSON 返回订单对象
+func (s *acmeStub) orderJSON(id int) map[string]any {
+	o := s.orders[id]
+	body := map[string]any{
+		"status":         o.status,
+		"identifiers":    []map[string]string{{"type": "dns", "value": o.domain}},
+		"authorizations": []string{fmt.Sprintf("%s/authz/%d", s.srv.URL, id)},
+		"finalize":       fmt.Sprintf("%s/finalize/%d", s.srv.URL, id),
+	}
+	if o.status == "valid" {
+		body["certificate"] = fmt.Sprintf("%s/cert/%d", s.srv.URL, id)
+	}
+	return body
+}
+
+// authzJSON 返回授权对象
+func (s *acmeStub) authzJSON(id int) map[string]any {
+	o := s.orders[id]
+	return map[string]any{
+		"status":     o.authzStatus,
+		"identifier": map[string]string{"type": "dns", "value": o.domain},
+		"challenges": []any{s.challengeJSON(id)},
+	}
+}
+
+// challengeJSON 返回验证对象
+func (s *acmeStub) challengeJSON(id int) map[string]any {
+	o := s.orders[id]
+	return map[string]any{
+		"type":   s.challengeType,
+		"url":    fmt.Sprintf("%s/chal/%d", s.srv.URL, id),
+		"token":  o.token,
+		"status": o.authzStatus,
+	}
+}
+
+// validate 按验证方式连接 validateAddr 检查密钥授权
+func (s *acmeStub) validate(o *stubOrder) bool {
+	keyAuth := o.token + "." + s.thumbprint
+	switch s.challengeType {
+	case "http-01":
+		req, _ := http.NewRequest(http.MethodGet, "http://"+s.validateAddr+"/.well-known/acme-challenge/"+o.token, nil)
+		req.Host = o.domain
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			s.t.Logf("HTTP-01 validation: %v", err)
+			return false
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode == http.StatusOK && strings.TrimSpace(string(body)) == keyAuth
+	case "tls-alpn-01":
+		conn, err := tls.Dial("tcp", s.validateAddr, &tls.Config{
+			ServerName:         o.domain,
+			NextProtos:         []string{acme.ALPNProto},
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			s.t.Logf("TLS-ALPN-01 validation: %v", err)
+			return false
+		}
+		defer conn.Close()
+		leaf := conn.ConnectionState().PeerCertificates[0]
+		want := sha256.Sum256([]byte(keyAuth))
+		for _, ext := range leaf.Extensions {
+			if ext.Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}) {
+				var got []byte
+				_, err := asn1.Unmarshal(ext.Value, &got)
+				return err == nil && bytes.Equal(got, want[:]) && leaf.VerifyHostname(o.domain) == nil
+			}
+		}
+	}
+	return false
+}
+
+// issue 使用 CSR 签发证书
+func (s *acmeStub) issue(o *stubOrder, csrText string) error {
+	if o.status != "ready" {
+		return fmt.Errorf("order is %s", o.status)
+	}
+	der, err := base64.RawURLEncoding.DecodeString(csrText)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, tmpl, s.caCert, csr.PublicKey, s.caKey)
+	if err != nil {
+		return err
+	}
+	o.certPEM = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})...)
+	o.status = "valid"
+	return nil
+}
+
+// stubJWSHeader JWS 受保护头中用到的字段
+type stubJWSHeader struct {
+	JWK json.RawMessage `json:"jwk"`
+}
+
+// parseStubJWS 解析 JWS 请求的受保护头与载荷
+func parseStubJWS(r io.Reader) (stubJWSHeader, []byte, error) {
+	var header stubJWSHeader
+	var msg struct{ Protected, Payload string }
+	if err := json.NewDecoder(r).Decode(&msg); err != nil {
+		return header, nil, err
+	}
+	protected, err := base64.RawURLEncoding.DecodeString(msg.Protected)
+	if err != nil {
+		return header, nil, err
+	}
+	if err := json.Unmarshal(protected, &header); err != nil {
+		return header, nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(msg.Payload)
+	return header, payload, err
+}
+
+// stubThumbprint 计算账户公钥（P-256 JWK）的指纹
+func stubThumbprint(jwk json.RawMessage) (string, error) {
+	var k struct{ X, Y string }
+	if err := json.Unmarshal(jwk, &k); err != nil {
+		return "", err
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return "", err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return "", err
+	}
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	return acme.JWKThumbprint(pub)
+}
+
+// writeStubJSON 输出 JSON 响应
+func writeStubJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// freePort 返回一个当前空闲的本地 TCP 端口
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// serveTLSHandshakes 使用 config 接受 TLS 连接并完成握手，模拟 HTTPS 监听
+func serveTLSHandshakes(t *testing.T, config *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestACMEIssuer(t *testing.T) {
+	const domain = "files.test"
+
+	tests := []struct {
+		name          string
+		challengeType string
+	}{
+		{"http-01", "http-01"},
+		{"tls-alpn-01", "tls-alpn-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, caFile := newACMEStub(t, tt.challengeType)
+			opt := ACMEOptions{
+				DirectoryURL: stub.srv.URL + "/dir",
+				Domains:      []string{domain},
+				CACertPath:   caFile,
+				CacheDir:     filepath.Join(t.TempDir(), "acme"),
+			}
+			if tt.challengeType == "http-01" {
+				opt.HTTPPort = freePort(t)
+			}
+
+			issuer, err := newACMEIssuer(opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fallback := &tls.Certificate{}
+			config := &tls.Config{GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return fallback, nil
+			}}
+			issuer.install(config)
+			tlsAddr := serveTLSHandshakes(t, config)
+			if err := issuer.start("127.0.0.1", 0); err != nil {
+				t.Fatal(err)
+			}
+			defer issuer.stop()
+
+			stub.validateAddr = tlsAddr
+			if opt.HTTPPort > 0 {
+				stub.validateAddr = net.JoinHostPort("127.0.0.1", strconv.Itoa(opt.HTTPPort))
+			}
+
+			// 首次申请
+			cert, err := issuer.manager.GetCertificate(prefetchHello(domain))
+			if err != nil {
+				t.Fatalf("issue: %v", err)
+			}
+			if stub.orderCount() != 1 {
+				t.Fatalf("orders = %d, want 1", stub.orderCount())
+			}
+
+			// 握手时 ACME 主机名使用签发的证书
+			roots := x509.NewCertPool()
+			roots.AddCert(stub.caCert)
+			conn, err := tls.Dial("tcp", tlsAddr, &tls.Config{ServerName: domain, RootCAs: roots})
+			if err != nil {
+				t.Fatalf("handshake with issued certificate: %v", err)
+			}
+			if got := conn.ConnectionState().PeerCertificates[0].Raw; !bytes.Equal(got, cert.Certificate[0]) {
+				t.Fatal("server did not present the ACME certificate")
+			}
+			conn.Close()
+
+			// 白名单之外的主机名不申请证书
+			if _, err := issuer.manager.GetCertificate(prefetchHello("other.test")); err == nil {
+				t.Fatal("certificate issued for a host outside -acme-domains")
+			}
+			if issuer.handles("other.test") || !issuer.handles("FILES.test.") {
+				t.Fatal("handles() does not match -acme-domains")
+			}
+			if stub.orderCount() != 1 {
+				t.Fatalf("orders = %d after non-whitelisted request, want 1", stub.orderCount())
+			}
+
+			// 重启后从缓存加载，不重新申请
+			issuer.stop()
+			opt.HTTPPort = 0
+			reused, err := newACMEIssuer(opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cached, err := reused.manager.GetCertificate(prefetchHello(domain))
+			if err != nil {
+				t.Fatalf("load from cache: %v", err)
+			}
+			if !bytes.Equal(cached.Certificate[0], cert.Certificate[0]) {
+				t.Fatal("cached certificate differs from the issued one")
+			}
+			if stub.orderCount() != 1 {
+				t.Fatalf("orders = %d after restart, want 1", stub.orderCount())
+			}
+		})
+	}
+}
+
+// roundTripFunc 以函数实现 http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip 实现 http.RoundTripper
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestOrderLocationTransport(t *testing.T) {
+	const orderBody = `{"status":"pending","finalize":"https://ca.test/finalize/1"}`
+	responses := map[string]*http.Response{
+		"POST https://ca.test/new-order": {
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Location": {"/order/1"}, "Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(orderBody)),
+		},
+		"POST https://ca.test/finalize/1": {
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"status":"processing"}`)),
+		},
+		"POST https://ca.test/finalize/2": {
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"status":"processing"}`)),
+		},
+		"GET https://ca.test/finalize/1": {
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("")),
+		},
+	}
+	transport := &orderLocationTransport{
+		base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return responses[r.Method+" "+r.URL.String()], nil
+		}),
+		orders: make(map[string]string),
+	}
+	do := func(method, url string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, url, nil)
+		res, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// newOrder 响应保持不变，响应体仍可读取
+	res := do(http.MethodPost, "https://ca.test/new-order")
+	if body, _ := io.ReadAll(res.Body); string(body) != orderBody {
+		t.Fatalf("new-order body = %q", body)
+	}
+	if got := res.Header.Get("Location"); got != "/order/1" {
+		t.Fatalf("new-order Location = %q", got)
+	}
+
+	tests := []struct {
+		method, url string
+		want        string
+	}{
+		{http.MethodPost, "https://ca.test/finalize/1", "https://ca.test/order/1"},
+		{http.MethodPost, "https://ca.test/finalize/2", ""}, // 未记录的订单
+		{http.MethodGet, "https://ca.test/finalize/1", ""},  // 只处理 POST
+	}
+	for _, tt := range tests {
+		if got := do(tt.method, tt.url).Header.Get("Location"); got != tt.want {
+			t.Errorf("%s %s: Location = %q, want %q", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
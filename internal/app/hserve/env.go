@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
+
+	"github.com/Alhkxsj/hserve/pkg/certgen"
 )
 
 // checkPort 检测端口是否可用
@@ -12,7 +15,7 @@ func checkPort(addr string) error {
 	if err != nil {
 		return formatPortError(addr, err)
 	}
-	
+
 	cleanupListener(ln)
 	return nil
 }
@@ -33,12 +36,19 @@ func cleanupListener(ln net.Listener) {
 }
 
 // PreflightCheck 运行前环境自检
-func PreflightCheck(addr, certPath, keyPath string) error {
+//
+// caCertPath 不为空时会验证服务器证书能否通过该 CA 验证；quiet 为 true 时不输出证书警告。
+func PreflightCheck(addr, certPath, keyPath, caCertPath string, quiet bool) error {
 	// 检查证书和私钥文件
 	if err := checkCertificateFiles(certPath, keyPath); err != nil {
 		return err
 	}
 
+	// 检查证书与私钥是否匹配、是否过期
+	if err := checkCertificateHealth(certPath, keyPath, caCertPath, quiet); err != nil {
+		return err
+	}
+
 	// 检查端口可用性
 	if err := checkPort(addr); err != nil {
 		return err
@@ -59,3 +69,21 @@ func checkCertificateFiles(certPath, keyPath string) error {
 
 	return nil
 }
+
+// checkCertificateHealth 检查证书与私钥是否匹配、是否过期以及证书链是否有效
+//
+// 警告（例如即将过期）在 quiet 为 true 时不输出，错误总是返回。
+func checkCertificateHealth(certPath, keyPath, caCertPath string, quiet bool) error {
+	report := certgen.Inspect(certPath, keyPath, caCertPath)
+
+	if !quiet {
+		for _, w := range report.Warnings {
+			fmt.Println("⚠️ ", w)
+		}
+	}
+
+	if !report.Healthy() {
+		return fmt.Errorf("证书检查未通过：\n  %s", strings.Join(report.Errors, "\n  "))
+	}
+	return nil
+}
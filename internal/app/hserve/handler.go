@@ -3,12 +3,16 @@ package server
 import (
 	"compress/gzip"
 	"crypto/subtle"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	tlspolicy "github.com/Alhkxsj/hserve/internal/tls"
+	"golang.org/x/net/webdav"
 )
 
 type loggingResponseWriter struct {
@@ -22,35 +26,79 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap 返回被包装的 ResponseWriter，供 http.ResponseController 使用
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+// HandlerOptions 文件服务处理器的选项
+type HandlerOptions struct {
+	Root           string         // 共享目录
+	Quiet          bool           // 不输出访问日志
+	Paths          []string       // 只允许访问的路径，为空时允许整个目录
+	TLSLog         bool           // 访问日志附带 TLS 版本、密码套件、ALPN、SNI 与会话复用信息
+	AltSvc         string         // Alt-Svc 响应头，用于通告 HTTP/3，为空时不发送
+	Upload         bool           // 允许通过表单 POST 与 PUT 上传文件
+	AllowOverwrite bool           // 允许上传覆盖已有文件
+	Staging        *UploadStaging // 可续传上传的暂存区，为空时不支持 tus 协议
+	WebDAV         bool           // 通过 WebDAV 提供共享目录，供文件管理器挂载
+}
+
 // NewHandler 创建一个新的 HTTP 处理器，提供文件服务功能
-func NewHandler(root string, quiet bool, paths []string) http.Handler {
-	fs := http.FileServer(http.Dir(root))
+func NewHandler(opt HandlerOptions) http.Handler {
+	fs := http.FileServer(http.Dir(opt.Root))
+
+	var dav *webdav.Handler
+	if opt.WebDAV {
+		dav = newWebDAVHandler(opt)
+	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handleRequest(w, r, fs, root, quiet, paths)
+		handleRequest(w, r, fs, dav, opt)
 	})
 }
 
 // handleRequest 处理 HTTP 请求的主要逻辑
-func handleRequest(w http.ResponseWriter, r *http.Request, fs http.Handler, root string, quiet bool, paths []string) {
+func handleRequest(w http.ResponseWriter, r *http.Request, fs http.Handler, dav *webdav.Handler, opt HandlerOptions) {
 	start := time.Now()
 
 	// 包装 ResponseWriter 以捕获状态码
 	lrw := createLoggingResponseWriter(w)
 
 	// 安全头部
-	secureHeaders(lrw)
+	secureHeaders(lrw, opt.AltSvc)
+
+	// 可续传上传，资源地址位于隐藏路径下，由 handleResumableUpload 自行检查目标路径
+	if opt.Upload && handleResumableUpload(lrw, r, opt) {
+		logRequest(r, lrw.statusCode, time.Since(start), opt.Quiet, opt.TLSLog)
+		return
+	}
 
 	// 检查请求安全性
-	if !isRequestAllowed(r.URL.Path, root, paths, len(paths) > 0) {
+	if !isRequestAllowed(r.URL.Path, opt.Root, opt.Paths, len(opt.Paths) > 0) {
 		http.Error(lrw, "Forbidden", http.StatusForbidden)
-		logRequest(r, lrw.statusCode, time.Since(start), quiet)
+		logRequest(r, lrw.statusCode, time.Since(start), opt.Quiet, opt.TLSLog)
 		return
 	}
 
-	fs.ServeHTTP(lrw, r)
+	// WebDAV
+	if dav != nil && handleWebDAV(lrw, r, dav, opt) {
+		logRequest(r, lrw.statusCode, time.Since(start), opt.Quiet, opt.TLSLog)
+		return
+	}
+
+	// 上传
+	if opt.Upload && handleUpload(lrw, r, opt) {
+		logRequest(r, lrw.statusCode, time.Since(start), opt.Quiet, opt.TLSLog)
+		return
+	}
+
+	// 目录列表，其他请求交给 http.FileServer
+	if !serveListing(lrw, r, opt) {
+		fs.ServeHTTP(lrw, r)
+	}
 
-	logRequest(r, lrw.statusCode, time.Since(start), quiet)
+	logRequest(r, lrw.statusCode, time.Since(start), opt.Quiet, opt.TLSLog)
 }
 
 // createLoggingResponseWriter 创建日志响应写入器
@@ -94,17 +142,59 @@ func isHiddenFileRequest(path string) bool {
 }
 
 // logRequest 记录 HTTP 请求信息
-func logRequest(r *http.Request, statusCode int, duration time.Duration, quiet bool) {
+func logRequest(r *http.Request, statusCode int, duration time.Duration, quiet, tlsLog bool) {
 	if !quiet {
-		fmt.Printf("[%s] %s %s %d %v\n",
+		fmt.Printf("[%s] %s %s %d %v %s%s\n",
 			time.Now().Format("15:04:05"),
 			r.Method,
 			r.URL.Path,
 			statusCode,
-			duration.Round(time.Millisecond))
+			duration.Round(time.Millisecond),
+			r.Proto,
+			tlsLogFields(r, tlsLog))
 	}
 }
 
+// tlsLogFields 返回访问日志中的 TLS 连接信息
+//
+// 协商了密钥交换算法时记录 curve=（工具链无法获取或会话复用未协商时省略）；
+// detailed 为 true 时附加版本、套件、ALPN、SNI 与会话复用状态。
+func tlsLogFields(r *http.Request, detailed bool) string {
+	if r.TLS == nil {
+		return ""
+	}
+
+	var fields string
+	if detailed {
+		alpn := r.TLS.NegotiatedProtocol
+		if alpn == "" {
+			alpn = "http/1.1"
+		}
+		sni := r.TLS.ServerName
+		if sni == "" {
+			sni = "-"
+		}
+		fields += fmt.Sprintf(" tls=%s cipher=%s alpn=%s sni=%s resumed=%s",
+			tlspolicy.VersionName(r.TLS.Version),
+			tls.CipherSuiteName(r.TLS.CipherSuite),
+			alpn,
+			sni,
+			yesNo(r.TLS.DidResume))
+	}
+	if curve := tlspolicy.NegotiatedCurve(r.TLS); curve != "" {
+		fields += " curve=" + curve
+	}
+	return fields
+}
+
+// yesNo 将布尔值转换为 yes / no
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
 // isPathAllowed 检查请求的路径是否在允许的路径列表中
 func isPathAllowed(requestPath string, allowedPaths []string, root string) bool {
 	// 如果没有指定允许的路径，则允许所有路径
@@ -177,7 +267,13 @@ func isPathSafe(requestPath, rootDir string) bool {
 
 	// 检查符号链接安全性
 	resolvedPath, err := filepath.EvalSymlinks(fullPath)
-	if err != nil && !os.IsNotExist(err) {
+	if os.IsNotExist(err) {
+		// 路径尚不存在（例如上传的目标文件），检查最近的已存在上级目录解析后的位置
+		resolvedPath, err = resolveMissingPath(fullPath)
+		if err != nil {
+			return false
+		}
+	} else if err != nil {
 		// 如果是符号链接错误，检查原始路径
 		resolvedPath = fullPath
 	}
@@ -191,7 +287,34 @@ func isPathSafe(requestPath, rootDir string) bool {
 	return !strings.HasPrefix(relResolvedPath, "..")
 }
 
-func secureHeaders(w http.ResponseWriter) {
+// resolveMissingPath 解析不存在的路径：对最近的已存在上级目录解析符号链接，再拼接其余部分
+//
+// 只解析直接父目录不够，缺失的父目录之上仍可能有指向共享目录之外的符号链接。
+func resolveMissingPath(fullPath string) (string, error) {
+	existing, rest := fullPath, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		// 存在但无法解析，是指向不存在目标的符号链接，写入时会在链接目标处创建文件
+		if _, lerr := os.Lstat(existing); lerr == nil {
+			return "", err
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+func secureHeaders(w http.ResponseWriter, altSvc string) {
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.Header().Set("X-Frame-Options", "DENY")
 	w.Header().Set("X-XSS-Protection", "1; mode=block")
@@ -199,6 +322,9 @@ func secureHeaders(w http.ResponseWriter) {
 	w.Header().Set("Content-Security-Policy", "default-src 'self'; font-src 'self' data:; img-src 'self' data:; style-src 'self' 'unsafe-inline'; script-src 'self';")
 	w.Header().Set("Referrer-Policy", "no-referrer")
 	w.Header().Set("Permissions-Policy", "geolocation=(), microphone=(), camera=()")
+	if altSvc != "" {
+		w.Header().Set("Alt-Svc", altSvc)
+	}
 }
 
 // GzipMiddleware 中间件提供 Gzip 压缩功能
@@ -260,6 +386,11 @@ func (gzw *gzipResponseWriter) WriteHeader(statusCode int) {
 	gzw.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap 返回被包装的 ResponseWriter，供 http.ResponseController 使用
+func (gzw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gzw.ResponseWriter
+}
+
 // BasicAuthMiddleware 中间件提供基本身份验证
 func BasicAuthMiddleware(username, password, realm string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -297,7 +428,7 @@ func isAuthenticated(r *http.Request, username, password, realm string, w http.R
 // isCredentialsValid 检查凭据是否有效
 func isCredentialsValid(user, pass, username, password string) bool {
 	return subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1 &&
-		   subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+		subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
 }
 
 // sendUnauthorizedResponse 发送未授权响应
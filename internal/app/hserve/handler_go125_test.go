@@ -0,0 +1,41 @@
+//go:build go1.25
+
+package server
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTLSLogFieldsCurve Go 1.25 起 ConnectionState 提供 CurveID，协商的密钥交换算法写入 curve=
+func TestTLSLogFieldsCurve(t *testing.T) {
+	tests := []struct {
+		name     string
+		curve    tls.CurveID
+		detailed bool
+		want     string
+	}{
+		{"hybrid", tls.X25519MLKEM768, false, " curve=X25519MLKEM768"},
+		{"classic", tls.X25519, false, " curve=X25519"},
+		{"none", 0, false, ""},
+		{"detailed", tls.X25519MLKEM768, true,
+			" tls=1.3 cipher=TLS_AES_128_GCM_SHA256 alpn=h2 sni=files.lan resumed=" + yesNo(false) + " curve=X25519MLKEM768"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.TLS = &tls.ConnectionState{
+				Version:            tls.VersionTLS13,
+				CipherSuite:        tls.TLS_AES_128_GCM_SHA256,
+				NegotiatedProtocol: "h2",
+				ServerName:         "files.lan",
+				CurveID:            tt.curve,
+			}
+			if got := tlsLogFields(req, tt.detailed); got != tt.want {
+				t.Fatalf("fields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
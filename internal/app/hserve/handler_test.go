@@ -0,0 +1,101 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPathSafe(t *testing.T) {
+	root := newTestRoot(t)
+	outside := newTestRoot(t)
+
+	mustMkdir(t, filepath.Join(root, "dir", "sub"))
+	mustMkdir(t, filepath.Join(outside, "secret"))
+	mustSymlink(t, outside, filepath.Join(root, "escape"))
+	mustSymlink(t, filepath.Join(root, "dir"), filepath.Join(root, "inside"))
+	mustSymlink(t, filepath.Join(outside, "missing"), filepath.Join(root, "dangling"))
+	mustSymlink(t, outside, filepath.Join(root, "dir", "escape"))
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/dir/sub", true},
+		{"/dir/new.txt", true},
+		{"/dir/missing/deeper/new.txt", true},
+		{"/inside/sub", true},
+		{"/inside/missing/new.txt", true},
+		{"/../etc/passwd", true}, // Clean 后位于共享目录内
+		{"/escape", false},
+		{"/escape/secret", false},
+		{"/escape/new.txt", false},
+		{"/escape/missing/deeper/new.txt", false},
+		{"/dir/escape/missing/new.txt", false},
+		{"/dangling", false},
+		{"/dangling/new.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isPathSafe(tt.path, root); got != tt.want {
+				t.Fatalf("isPathSafe(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRequestAllowed(t *testing.T) {
+	root := newTestRoot(t)
+	mustMkdir(t, filepath.Join(root, "pub", "docs"))
+	mustMkdir(t, filepath.Join(root, "private"))
+
+	tests := []struct {
+		path  string
+		paths []string
+		want  bool
+	}{
+		{"/pub/docs", nil, true},
+		{"/.git/config", nil, false},
+		{"/pub/.env", nil, false},
+		{"/", []string{filepath.Join(root, "pub")}, true},
+		{"/pub/docs", []string{filepath.Join(root, "pub")}, true},
+		{"/private", []string{filepath.Join(root, "pub")}, false},
+		{"/public", []string{filepath.Join(root, "pub")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isRequestAllowed(tt.path, root, tt.paths, len(tt.paths) > 0); got != tt.want {
+				t.Fatalf("isRequestAllowed(%q, %v) = %v, want %v", tt.path, tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSLogFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    *tls.ConnectionState
+		detailed bool
+		want     string
+	}{
+		{"plain http", nil, true, ""},
+		{"no curve", &tls.ConnectionState{Version: tls.VersionTLS13}, false, ""},
+		{"detailed without curve", &tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256, DidResume: true}, true,
+			" tls=1.3 cipher=TLS_AES_128_GCM_SHA256 alpn=http/1.1 sni=- resumed=" + yesNo(true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.TLS = tt.state
+			got := tlsLogFields(req, tt.detailed)
+			if got != tt.want {
+				t.Fatalf("fields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
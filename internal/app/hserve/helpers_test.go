@@ -0,0 +1,234 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestTLSCertificate 创建包含指定主机名的自签名证书，返回证书与信任它的证书池
+func newTestTLSCertificate(t *testing.T, names ...string) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: names[0]},
+		DNSNames:              names,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// newTestRoot 创建测试用的共享目录，返回解析符号链接后的路径
+func newTestRoot(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// mustMkdir 创建目录，失败时终止测试
+func mustMkdir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mustSymlink 创建符号链接，失败时终止测试
+func mustSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("不支持符号链接: %v", err)
+	}
+}
+
+// writeTestFile 在共享目录中创建文件
+func writeTestFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	mustMkdir(t, filepath.Dir(p))
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newWebDAVTest 创建开启 WebDAV 的处理器
+func newWebDAVTest(t *testing.T, opt HandlerOptions) (http.Handler, string) {
+	t.Helper()
+	if opt.Root == "" {
+		opt.Root = newTestRoot(t)
+	}
+	opt.Quiet = true
+	opt.WebDAV = true
+	return NewHandler(opt), opt.Root
+}
+
+// serveTestRequest 发送请求并返回响应
+func serveTestRequest(handler http.Handler, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// errReader 读取时返回错误，模拟上传中断
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+// assertNoTempFiles 检查共享目录中没有残留的上传临时文件
+func assertNoTempFiles(t *testing.T, root string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(root, ".hserve-upload-*"))
+	if len(matches) > 0 {
+		t.Fatalf("temporary files left behind: %v", matches)
+	}
+}
+
+// newListingRoot 创建用于目录列表测试的共享目录
+func newListingRoot(t *testing.T) string {
+	t.Helper()
+	root := newTestRoot(t)
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"b.txt", 300, 3 * time.Hour},
+		{"A.jpg", 100, 1 * time.Hour},
+		{"c.zip", 200, 2 * time.Hour},
+		{"docs/readme.md", 1, 0},
+		{".hidden", 1, 0},
+	}
+	for _, f := range files {
+		p := filepath.Join(root, filepath.FromSlash(f.name))
+		writeTestFile(t, root, f.name, strings.Repeat("x", f.size))
+		mtime := time.Now().Add(-f.age)
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// tusTest 可续传上传测试环境
+type tusTest struct {
+	t       *testing.T
+	root    string
+	staging *UploadStaging
+	handler http.Handler
+}
+
+// newTusTest 创建开启上传与 tus 的处理器
+func newTusTest(t *testing.T, maxSize int64, overwrite bool) *tusTest {
+	t.Helper()
+	root := newTestRoot(t)
+	staging, err := NewUploadStaging(filepath.Join(t.TempDir(), "uploads"), maxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHandler(HandlerOptions{
+		Root:           root,
+		Quiet:          true,
+		Upload:         true,
+		AllowOverwrite: overwrite,
+		Staging:        staging,
+	})
+	return &tusTest{t: t, root: root, staging: staging, handler: handler}
+}
+
+// do 发送请求并返回响应
+func (tt *tusTest) do(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", tusVersion)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	tt.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// create 创建上传并返回其地址
+func (tt *tusTest) create(name string, length int) string {
+	tt.t.Helper()
+	rec := tt.do(http.MethodPost, "/", "", map[string]string{
+		"Upload-Length":   strconv.Itoa(length),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte(name)),
+	})
+	if rec.Code != http.StatusCreated {
+		tt.t.Fatalf("create status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	return rec.Header().Get("Location")
+}
+
+// patch 从 offset 处发送数据
+func (tt *tusTest) patch(location string, offset int, data string, headers map[string]string) *httptest.ResponseRecorder {
+	h := map[string]string{
+		"Content-Type":  tusChunkType,
+		"Upload-Offset": strconv.Itoa(offset),
+	}
+	for k, v := range headers {
+		h[k] = v
+	}
+	return tt.do(http.MethodPatch, location, data, h)
+}
+
+// offset 通过 HEAD 取得当前偏移量
+func (tt *tusTest) offset(location string) (int, int) {
+	rec := tt.do(http.MethodHead, location, "", nil)
+	if rec.Code != http.StatusOK {
+		return rec.Code, -1
+	}
+	n, _ := strconv.Atoi(rec.Header().Get("Upload-Offset"))
+	return rec.Code, n
+}
+
+// readFile 读取共享目录中的文件
+func (tt *tusTest) readFile(name string) string {
+	tt.t.Helper()
+	data, err := os.ReadFile(filepath.Join(tt.root, name))
+	if err != nil {
+		tt.t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTP/2 参数的有效范围（RFC 9113 与 net/http 的限制）
+const (
+	minHTTP2FrameSize     = 16 << 10
+	maxHTTP2FrameSize     = 1<<24 - 1
+	maxHTTP2ReceiveBuffer = 4<<20 - 1
+)
+
+// net/http 的 HTTP/2 默认值，仅用于显示
+const (
+	defaultHTTP2MaxStreams    = 250
+	defaultHTTP2FrameSize     = 1 << 20
+	defaultHTTP2ReceiveBuffer = 1 << 20
+)
+
+// validateHTTP2Options 检查 HTTP/2 参数，0 表示使用默认值
+func validateHTTP2Options(opt Options) error {
+	if opt.H2MaxStreams < 0 {
+		return fmt.Errorf("-h2-max-streams 不能为负数: %d", opt.H2MaxStreams)
+	}
+	if opt.H2MaxFrameSize != 0 && (opt.H2MaxFrameSize < minHTTP2FrameSize || opt.H2MaxFrameSize > maxHTTP2FrameSize) {
+		return fmt.Errorf("-h2-max-frame-size 需在 %d 到 %d 之间: %d", minHTTP2FrameSize, maxHTTP2FrameSize, opt.H2MaxFrameSize)
+	}
+	if opt.H2StreamWindow < 0 || opt.H2StreamWindow > maxHTTP2ReceiveBuffer {
+		return fmt.Errorf("-h2-stream-window 需在 0 到 %d 之间: %d", maxHTTP2ReceiveBuffer, opt.H2StreamWindow)
+	}
+	return nil
+}
+
+// configureHTTP2 设置服务器的 HTTP/2 参数，禁用时只协商 HTTP/1.1
+func configureHTTP2(srv *http.Server, opt Options) {
+	if opt.DisableHTTP2 {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		return
+	}
+
+	srv.HTTP2 = &http.HTTP2Config{
+		MaxConcurrentStreams:      opt.H2MaxStreams,
+		MaxReadFrameSize:          opt.H2MaxFrameSize,
+		MaxReceiveBufferPerStream: opt.H2StreamWindow,
+	}
+}
+
+// describeHTTP2 返回 HTTP/2 设置的说明
+func describeHTTP2(opt Options) string {
+	if opt.DisableHTTP2 {
+		return "已关闭（仅 HTTP/1.1）"
+	}
+
+	return fmt.Sprintf("已启用 (最大并发流=%d, 最大帧=%d, 单流接收窗口=%d)",
+		orDefault(opt.H2MaxStreams, defaultHTTP2MaxStreams),
+		orDefault(opt.H2MaxFrameSize, defaultHTTP2FrameSize),
+		orDefault(opt.H2StreamWindow, defaultHTTP2ReceiveBuffer))
+}
+
+// orDefault 值为 0 时返回默认值
+func orDefault(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3MaxAge Alt-Svc 中 HTTP/3 通告的有效期（秒）
+const http3MaxAge = 86400
+
+// altSvcHeader 返回通告 HTTP/3 的 Alt-Svc 响应头，未启用 HTTP/3 时为空
+func altSvcHeader(opt Options) string {
+	if !opt.HTTP3 {
+		return ""
+	}
+	_, port, err := net.SplitHostPort(opt.Addr)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf(`h3=":%s"; ma=%d`, port, http3MaxAge)
+}
+
+// http3Server 与 HTTPS 共用端口号的 HTTP/3 服务器
+type http3Server struct {
+	srv  *http3.Server
+	conn net.PacketConn
+}
+
+// startHTTP3 在与 srv 相同的 UDP 端口上启动 HTTP/3 服务器
+//
+// 复用 srv 的请求处理器与 TLS 配置（证书、策略与客户端证书验证），QUIC 固定使用 TLS 1.3。
+func startHTTP3(srv *http.Server) (*http3Server, error) {
+	conn, err := net.ListenPacket("udp", srv.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("UDP 端口 %s 无法监听，可能已被占用", srv.Addr)
+	}
+
+	h := &http3Server{
+		srv: &http3.Server{
+			Handler:   srv.Handler,
+			TLSConfig: srv.TLSConfig,
+			// 0-RTT 数据可被重放，对上传等非幂等请求不安全，因此关闭
+			QUICConfig: &quic.Config{
+				Allow0RTT:      false,
+				MaxIdleTimeout: srv.IdleTimeout,
+			},
+			MaxHeaderBytes: srv.MaxHeaderBytes,
+			IdleTimeout:    srv.IdleTimeout,
+		},
+		conn: conn,
+	}
+
+	go func() {
+		if err := h.srv.Serve(conn); err != nil && err != http.ErrServerClosed {
+			fmt.Println("❌ HTTP/3 服务器已停止:", err)
+		}
+	}()
+
+	return h, nil
+}
+
+// Close 优雅关闭 HTTP/3 服务器，5 秒后强制关闭
+func (h *http3Server) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = h.srv.Shutdown(ctx)
+	_ = h.conn.Close()
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAltSvcHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Options
+		want string
+	}{
+		{"disabled", Options{Addr: ":8443"}, ""},
+		{"any address", Options{Addr: ":8443", HTTP3: true}, `h3=":8443"; ma=86400`},
+		{"ipv4", Options{Addr: "0.0.0.0:443", HTTP3: true}, `h3=":443"; ma=86400`},
+		{"ipv6", Options{Addr: "[::1]:9443", HTTP3: true}, `h3=":9443"; ma=86400`},
+		{"invalid address", Options{Addr: "8443", HTTP3: true}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := altSvcHeader(tt.opt); got != tt.want {
+				t.Fatalf("altSvcHeader = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandlerAltSvc 处理器在所有响应上通告与 HTTPS 相同的端口
+func TestHandlerAltSvc(t *testing.T) {
+	tests := []struct {
+		name  string
+		http3 bool
+		path  string
+		code  int
+		want  string
+	}{
+		{"listing", true, "/", http.StatusOK, `h3=":9443"; ma=86400`},
+		{"not found", true, "/missing", http.StatusNotFound, `h3=":9443"; ma=86400`},
+		{"disabled", false, "/", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := Options{Root: t.TempDir(), Addr: "0.0.0.0:9443", HTTP3: tt.http3, Quiet: true}
+			h := NewHandler(handlerOptions(opt, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "https://localhost:9443/", nil)
+			req.URL.Path = tt.path
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if got := rec.Header().Get("Alt-Svc"); got != tt.want {
+				t.Fatalf("Alt-Svc = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
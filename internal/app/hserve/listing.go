@@ -0,0 +1,359 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// listingHTML 目录列表页模板，只使用内联样式，不含脚本，符合 secureHeaders 的 CSP
+//
+//go:embed templates/listing.html
+var listingHTML string
+
+// listingTemplate 目录列表页
+var listingTemplate = template.Must(template.New("listing").Parse(listingHTML))
+
+// listingSortKeys 支持的排序字段
+var listingSortKeys = []struct {
+	key   string
+	label string
+	class string
+}{
+	{"name", "名称", "name"},
+	{"size", "大小", "size"},
+	{"time", "修改时间", "time"},
+}
+
+// fileIcons 按扩展名区分的文件图标
+var fileIcons = map[string]string{
+	".jpg": "🖼️", ".jpeg": "🖼️", ".png": "🖼️", ".gif": "🖼️", ".webp": "🖼️", ".svg": "🖼️", ".bmp": "🖼️", ".heic": "🖼️",
+	".mp4": "🎬", ".mkv": "🎬", ".mov": "🎬", ".avi": "🎬", ".webm": "🎬",
+	".mp3": "🎵", ".flac": "🎵", ".wav": "🎵", ".ogg": "🎵", ".m4a": "🎵",
+	".zip": "📦", ".tar": "📦", ".gz": "📦", ".tgz": "📦", ".xz": "📦", ".7z": "📦", ".rar": "📦", ".zst": "📦",
+	".pdf": "📕",
+	".apk": "🤖",
+	".txt": "📝", ".md": "📝", ".log": "📝", ".csv": "📝",
+	".go": "💻", ".py": "💻", ".js": "💻", ".ts": "💻", ".sh": "💻", ".c": "💻", ".h": "💻", ".java": "💻", ".kt": "💻", ".rs": "💻",
+	".html": "🌐", ".htm": "🌐", ".css": "🌐", ".json": "🌐", ".xml": "🌐", ".yaml": "🌐", ".yml": "🌐",
+	".pem": "🔑", ".crt": "🔑", ".cer": "🔑", ".key": "🔑", ".p12": "🔑",
+}
+
+// breadcrumb 路径导航中的一级
+type breadcrumb struct {
+	Name string
+	URL  string
+}
+
+// sortColumn 可点击排序的表头
+type sortColumn struct {
+	Label string
+	Class string
+	URL   string
+	Arrow string
+}
+
+// listingEntry 目录中的一项
+type listingEntry struct {
+	Name    string
+	URL     string
+	Icon    string
+	IsDir   bool
+	Size    string
+	ModTime string
+
+	size    int64
+	modTime time.Time
+	absURL  string // 从共享目录根开始的地址
+}
+
+// listingJSONEntry JSON 目录列表中的一项
+type listingJSONEntry struct {
+	Name    string    `json:"name"`
+	Type    string    `json:"type"` // file | dir
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	MIME    string    `json:"mime,omitempty"`
+	URL     string    `json:"url"`
+}
+
+// listingPage 目录列表页数据
+type listingPage struct {
+	Path        string
+	Breadcrumbs []breadcrumb
+	Parent      string
+	Columns     []sortColumn
+	Entries     []listingEntry
+	Summary     string
+	Upload      bool // 显示上传表单
+}
+
+// serveListing 为目录请求渲染列表页，返回 false 表示不是目录列表请求
+//
+// 请求 JSON（Accept: application/json 或 ?format=json）时返回 JSON 数组。
+// 请求 HTML 且目录中存在 index.html，或路径不以 / 结尾时交给 http.FileServer 处理（返回首页或重定向）。
+func serveListing(w http.ResponseWriter, r *http.Request, opt HandlerOptions) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		return false
+	}
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	dir := filepath.Join(opt.Root, filepath.FromSlash(urlPath))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return false
+	}
+	asJSON := wantsJSONListing(r)
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); err == nil && !asJSON {
+		return false
+	}
+
+	entries, err := readListingEntries(dir, urlPath, opt)
+	if err != nil {
+		http.Error(w, "Error reading directory", http.StatusInternalServerError)
+		return true
+	}
+
+	sortKey, desc := listingSortOrder(r.URL.Query())
+	sortListingEntries(entries, sortKey, desc)
+
+	// 同一地址按 Accept 返回不同内容，缓存需要区分
+	w.Header().Add("Vary", "Accept")
+	if asJSON {
+		writeJSONListing(w, entries)
+		return true
+	}
+
+	page := listingPage{
+		Path:        urlPath,
+		Breadcrumbs: buildBreadcrumbs(urlPath),
+		Columns:     buildSortColumns(sortKey, desc),
+		Entries:     entries,
+		Summary:     listingSummary(entries),
+		Upload:      opt.Upload,
+	}
+	if urlPath != "/" {
+		page.Parent = "../"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = listingTemplate.Execute(w, page)
+	return true
+}
+
+// readListingEntries 读取目录内容，隐藏文件与不允许访问的路径不会列出
+func readListingEntries(dir, urlPath string, opt HandlerOptions) ([]listingEntry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []listingEntry
+	for _, de := range dirEntries {
+		name := de.Name()
+		if !isRequestAllowed(path.Join(urlPath, name), opt.Root, opt.Paths, len(opt.Paths) > 0) {
+			continue
+		}
+
+		// 符号链接按目标类型显示
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+
+		entry := listingEntry{
+			Name:    name,
+			IsDir:   info.IsDir(),
+			ModTime: info.ModTime().Format("2006-01-02 15:04"),
+			modTime: info.ModTime(),
+		}
+
+		// url.URL 会为含冒号的名称加上 ./，避免被解析为协议
+		link := url.URL{Path: name}
+		entry.URL = link.String()
+		abs := url.URL{Path: path.Join(urlPath, name)}
+		entry.absURL = abs.String()
+		if entry.IsDir {
+			entry.URL += "/"
+			entry.absURL += "/"
+			entry.Icon = "📁"
+			entry.Size = "-"
+		} else {
+			entry.Icon = fileIcon(name)
+			entry.size = info.Size()
+			entry.Size = formatSize(info.Size())
+		}
+
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// wantsJSONListing 检查客户端是否请求 JSON 格式的目录列表
+func wantsJSONListing(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSONListing 以 JSON 数组输出目录内容
+func writeJSONListing(w http.ResponseWriter, entries []listingEntry) {
+	items := make([]listingJSONEntry, 0, len(entries))
+	for _, e := range entries {
+		item := listingJSONEntry{
+			Name:    e.Name,
+			Type:    "file",
+			Size:    e.size,
+			ModTime: e.modTime.UTC(),
+			URL:     e.absURL,
+		}
+		if e.IsDir {
+			item.Type = "dir"
+		} else {
+			item.MIME = mime.TypeByExtension(filepath.Ext(e.Name))
+			if item.MIME == "" {
+				item.MIME = "application/octet-stream"
+			}
+		}
+		items = append(items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(items)
+}
+
+// listingSortOrder 解析 sort 与 order 查询参数，默认按名称升序
+func listingSortOrder(query url.Values) (string, bool) {
+	key := query.Get("sort")
+	switch key {
+	case "name", "size", "time":
+	default:
+		key = "name"
+	}
+	return key, query.Get("order") == "desc"
+}
+
+// sortListingEntries 排序目录内容，目录始终排在文件之前
+func sortListingEntries(entries []listingEntry, key string, desc bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+
+		var less, equal bool
+		switch key {
+		case "size":
+			less, equal = a.size < b.size, a.size == b.size
+		case "time":
+			less, equal = a.modTime.Before(b.modTime), a.modTime.Equal(b.modTime)
+		default:
+			an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name)
+			less, equal = an < bn, an == bn
+		}
+
+		if equal {
+			return a.Name < b.Name
+		}
+		if desc {
+			return !less
+		}
+		return less
+	})
+}
+
+// buildSortColumns 生成表头排序链接，点击当前排序列时切换升降序
+func buildSortColumns(sortKey string, desc bool) []sortColumn {
+	var columns []sortColumn
+	for _, k := range listingSortKeys {
+		col := sortColumn{Label: k.label, Class: k.class}
+
+		order := "asc"
+		if k.key == sortKey {
+			if desc {
+				col.Arrow = " ↓"
+			} else {
+				col.Arrow = " ↑"
+				order = "desc"
+			}
+		}
+		col.URL = "?sort=" + k.key + "&order=" + order
+
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// buildBreadcrumbs 生成路径导航
+func buildBreadcrumbs(urlPath string) []breadcrumb {
+	crumbs := []breadcrumb{{Name: "🏠", URL: "/"}}
+
+	current := "/"
+	for _, segment := range strings.Split(strings.Trim(urlPath, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		current += segment + "/"
+		link := url.URL{Path: current}
+		crumbs = append(crumbs, breadcrumb{Name: segment, URL: link.String()})
+	}
+	return crumbs
+}
+
+// listingSummary 返回目录与文件数量及文件总大小
+func listingSummary(entries []listingEntry) string {
+	var dirs, files int
+	var total int64
+	for _, e := range entries {
+		if e.IsDir {
+			dirs++
+		} else {
+			files++
+			total += e.size
+		}
+	}
+	return fmt.Sprintf("%d 个目录，%d 个文件，共 %s", dirs, files, formatSize(total))
+}
+
+// fileIcon 根据扩展名返回文件图标
+func fileIcon(name string) string {
+	if icon, ok := fileIcons[strings.ToLower(filepath.Ext(name))]; ok {
+		return icon
+	}
+	return "📄"
+}
+
+// formatSize 将字节数转换为易读的大小
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortListingEntries(t *testing.T) {
+	root := newListingRoot(t)
+	entries, err := readListingEntries(root, "/", HandlerOptions{Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		desc bool
+		want []string
+	}{
+		{"name", false, []string{"docs", "A.jpg", "b.txt", "c.zip"}},
+		{"name", true, []string{"docs", "c.zip", "b.txt", "A.jpg"}},
+		{"size", false, []string{"docs", "A.jpg", "c.zip", "b.txt"}},
+		{"size", true, []string{"docs", "b.txt", "c.zip", "A.jpg"}},
+		{"time", false, []string{"docs", "b.txt", "c.zip", "A.jpg"}},
+		{"time", true, []string{"docs", "A.jpg", "c.zip", "b.txt"}},
+	}
+
+	for _, tt := range tests {
+		name := tt.key
+		if tt.desc {
+			name += " desc"
+		}
+		t.Run(name, func(t *testing.T) {
+			sortListingEntries(entries, tt.key, tt.desc)
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListingSortOrder(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantKey  string
+		wantDesc bool
+	}{
+		{"", "name", false},
+		{"sort=size", "size", false},
+		{"sort=time&order=desc", "time", true},
+		{"sort=bogus&order=desc", "name", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		key, desc := listingSortOrder(req.URL.Query())
+		if key != tt.wantKey || desc != tt.wantDesc {
+			t.Errorf("%q: got (%s, %v), want (%s, %v)", tt.query, key, desc, tt.wantKey, tt.wantDesc)
+		}
+	}
+}
+
+func TestServeListingHTML(t *testing.T) {
+	root := newListingRoot(t)
+	writeTestFile(t, root, "a<b>.txt", "x")
+	writeTestFile(t, root, "site/index.html", "<h1>home</h1>")
+	handler := NewHandler(HandlerOptions{Root: root, Quiet: true})
+
+	tests := []struct {
+		name        string
+		target      string
+		want        int
+		contains    []string
+		notContains []string
+	}{
+		{"root", "/", http.StatusOK, []string{`href="b.txt"`, `href="docs/"`, "a&lt;b&gt;.txt"}, []string{".hidden", "<b>.txt", "../"}},
+		{"subdir", "/docs/", http.StatusOK, []string{`href="readme.md"`, `href="../"`, `href="/docs/"`}, nil},
+		{"index.html", "/site/", http.StatusOK, []string{"<h1>home</h1>"}, []string{"<table>"}},
+		{"no trailing slash", "/docs", http.StatusMovedPermanently, nil, nil},
+		{"hidden dir", "/.hidden/", http.StatusForbidden, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveTestRequest(handler, http.MethodGet, tt.target, nil)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			body := rec.Body.String()
+			for _, s := range tt.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("response missing %q", s)
+				}
+			}
+			for _, s := range tt.notContains {
+				if strings.Contains(body, s) {
+					t.Errorf("response contains %q", s)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 << 20, "5.0 MB"},
+		{3 << 30, "3.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestServeListingJSON(t *testing.T) {
+	root := newListingRoot(t)
+	writeTestFile(t, root, "site/index.html", "<h1>home</h1>")
+	writeTestFile(t, root, "docs/a b#1.txt", "x")
+	handler := NewHandler(HandlerOptions{Root: root, Quiet: true})
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    []listingJSONEntry
+	}{
+		{"accept header", "/?sort=size", map[string]string{"Accept": "text/html;q=0.9, application/json"}, []listingJSONEntry{
+			{Name: "docs", Type: "dir", URL: "/docs/"},
+			{Name: "site", Type: "dir", URL: "/site/"},
+			{Name: "A.jpg", Type: "file", Size: 100, MIME: "image/jpeg", URL: "/A.jpg"},
+			{Name: "c.zip", Type: "file", Size: 200, URL: "/c.zip"},
+			{Name: "b.txt", Type: "file", Size: 300, URL: "/b.txt"},
+		}},
+		{"format query", "/docs/?format=json", nil, []listingJSONEntry{
+			{Name: "a b#1.txt", Type: "file", Size: 1, URL: "/docs/a%20b%231.txt"},
+			{Name: "readme.md", Type: "file", Size: 1, URL: "/docs/readme.md"},
+		}},
+		{"ignores index.html", "/site/?format=json", nil, []listingJSONEntry{
+			{Name: "index.html", Type: "file", Size: 13, MIME: "text/html; charset=utf-8", URL: "/site/index.html"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveTestRequest(handler, http.MethodGet, tt.target, tt.headers)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Fatalf("Content-Type = %q", ct)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("Vary = %q, want Accept", vary)
+			}
+
+			var got []listingJSONEntry
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				g := got[i]
+				if g.ModTime.IsZero() || g.ModTime.Location() != time.UTC {
+					t.Errorf("%s: mtime %v not in UTC", g.Name, g.ModTime)
+				}
+				g.ModTime = time.Time{}
+				// 扩展名与 MIME 的对应关系部分来自系统，只检查内置的类型
+				if g.Type == "file" && g.MIME == "" {
+					t.Errorf("%s: missing mime", g.Name)
+				}
+				if want.MIME == "" {
+					g.MIME = ""
+				}
+				if g != want {
+					t.Errorf("entry %d = %+v, want %+v", i, g, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWantsJSONListing(t *testing.T) {
+	tests := []struct {
+		target string
+		accept string
+		want   bool
+	}{
+		{"/", "", false},
+		{"/", "text/html,application/xhtml+xml,*/*;q=0.8", false},
+		{"/", "application/json", true},
+		{"/", "application/json; charset=utf-8", true},
+		{"/?format=json", "text/html", true},
+		{"/?format=xml", "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := wantsJSONListing(req); got != tt.want {
+			t.Errorf("%s Accept=%q: got %v, want %v", tt.target, tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Alhkxsj/hserve/pkg/certgen"
+)
+
+// caMIMEType 浏览器识别为“安装 CA 证书”的 MIME 类型
+const caMIMEType = "application/x-x509-ca-cert"
+
+// onboarding 证书引导页
+//
+// 手机首次访问时尚未信任 CA，因此引导页只能通过明文 HTTP 提供。
+// 页面与终端同时显示 CA 指纹，安装前可人工比对以防被中间人替换。
+type onboarding struct {
+	URL         string
+	Fingerprint string
+	srv         *http.Server
+}
+
+// startOnboarding 在 opt.OnboardPort 上启动证书引导页
+func startOnboarding(opt Options) (*onboarding, error) {
+	if opt.CACertPath == "" {
+		return nil, fmt.Errorf("启用 -onboard-port 需要 CA 证书，请使用 -ca 指定")
+	}
+
+	ca, err := readLeafCertificate(opt.CACertPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+	}
+
+	host, _, err := net.SplitHostPort(opt.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的监听地址 %s: %w", opt.Addr, err)
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(opt.OnboardPort))
+
+	ln, err := createListener(addr)
+	if err != nil {
+		return nil, formatPortError(addr, err)
+	}
+
+	ob := &onboarding{
+		URL:         "http://" + net.JoinHostPort(onboardHost(host), strconv.Itoa(opt.OnboardPort)) + "/",
+		Fingerprint: certgen.Fingerprint(ca),
+	}
+	ob.srv = &http.Server{
+		Handler:           newOnboardHandler(ca, ob.Fingerprint),
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+
+	go func() {
+		if err := ob.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Println("❌ 证书引导页已停止:", err)
+		}
+	}()
+
+	return ob, nil
+}
+
+// Close 关闭证书引导页
+func (ob *onboarding) Close() {
+	_ = ob.srv.Close()
+}
+
+// onboardHost 返回引导页 URL 中使用的主机
+//
+// 监听所有地址时选用首选的局域网地址，方便手机扫码访问。
+func onboardHost(listenHost string) string {
+	return accessHosts(listenHost)[0]
+}
+
+// onboardPage 引导页模板（不含脚本与外部资源）
+var onboardPage = template.Must(template.New("onboard").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>hserve 证书安装</title>
+</head>
+<body>
+<h1>安装 hserve CA 证书</h1>
+<p>安装后，本设备访问 hserve 时将不再提示证书不安全。</p>
+<ul>
+<li><a href="/hserve-ca.crt">下载 CA 证书（PEM，Android / Linux / iOS）</a></li>
+<li><a href="/hserve-ca.cer">下载 CA 证书（DER，Windows / Android）</a></li>
+</ul>
+<p>安装前请确认下方 SHA-256 指纹与服务器终端中显示的一致：</p>
+<pre>{{.Fingerprint}}</pre>
+<p>证书名称：{{.Subject}}<br>有效期至：{{.NotAfter}}</p>
+<p>Android：设置 → 安全 → 加密与凭据 → 安装证书 → CA 证书。<br>
+iOS：下载后在 设置 → 通用 → VPN与设备管理 中安装描述文件，再到 设置 → 通用 → 关于本机 → 证书信任设置 中启用完全信任。</p>
+</body>
+</html>
+`))
+
+// newOnboardHandler 创建引导页处理器
+func newOnboardHandler(ca *x509.Certificate, fingerprint string) http.Handler {
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	page := struct {
+		Fingerprint string
+		Subject     string
+		NotAfter    string
+	}{fingerprint, ca.Subject.CommonName, ca.NotAfter.Format("2006-01-02")}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		onboardHeaders(w)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = onboardPage.Execute(w, page)
+	})
+	mux.HandleFunc("/hserve-ca.crt", serveCACertificate("hserve-ca.crt", pemData))
+	mux.HandleFunc("/hserve-ca.cer", serveCACertificate("hserve-ca.cer", ca.Raw))
+
+	return allowReadOnly(mux)
+}
+
+// serveCACertificate 以 CA 证书 MIME 类型返回证书内容
+func serveCACertificate(name string, data []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		onboardHeaders(w)
+		w.Header().Set("Content-Type", caMIMEType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method != http.MethodHead {
+			_, _ = w.Write(data)
+		}
+	}
+}
+
+// onboardHeaders 设置引导页安全响应头
+func onboardHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// allowReadOnly 仅允许 GET 和 HEAD 请求
+func allowReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// printOnboarding 输出引导页地址、CA 指纹及二维码
+func printOnboarding(ob *onboarding, showQR bool) {
+	fmt.Printf("📲 证书引导页: %s\n", ob.URL)
+	fmt.Printf("🔏 CA 指纹 (SHA-256): %s\n", ob.Fingerprint)
+
+	if showQR {
+		printQRCode("📷 用手机扫描二维码安装 CA 证书:", ob.URL)
+	}
+}
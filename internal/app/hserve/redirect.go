@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tlsRecordTypeHandshake TLS 握手记录的类型字节，客户端发送的第一个字节总是它
+const tlsRecordTypeHandshake = 0x16
+
+// plaintextHeaderLimit 读取 HTTPS 端口上明文请求头的上限
+const plaintextHeaderLimit = 64 << 10
+
+// errPlaintextRedirected 在 HTTPS 端口上收到明文 HTTP 请求并已返回重定向
+var errPlaintextRedirected = errors.New("plaintext HTTP request redirected to HTTPS")
+
+// httpsRedirectURL 返回请求对应的 HTTPS 地址，保留路径与查询参数
+func httpsRedirectURL(r *http.Request, httpsPort string) string {
+	host := requestHost(r)
+	if host == "" {
+		host = "localhost"
+	}
+
+	// 443 是 HTTPS 默认端口，省略后地址更简洁
+	hostport := net.JoinHostPort(host, httpsPort)
+	if httpsPort == "443" {
+		hostport = strings.TrimSuffix(hostport, ":443")
+	}
+	return "https://" + hostport + r.URL.RequestURI()
+}
+
+// redirectHandler 将所有请求 308 重定向到 HTTPS 端口
+//
+// 308 要求客户端保留请求方法与请求体，上传等 POST 请求重定向后仍然有效。
+func redirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, httpsRedirectURL(r, httpsPort), http.StatusPermanentRedirect)
+	})
+}
+
+// startRedirectServer 在 opt.HTTPPort 上启动明文 HTTP 重定向服务器
+func startRedirectServer(opt Options, handler http.Handler) (*http.Server, error) {
+	host, _, err := net.SplitHostPort(opt.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的监听地址 %s: %w", opt.Addr, err)
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(opt.HTTPPort))
+
+	ln, err := createListener(addr)
+	if err != nil {
+		return nil, formatPortError(addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Println("❌ HTTP 重定向服务器已停止:", err)
+		}
+	}()
+
+	return srv, nil
+}
+
+// sniffListener 识别 HTTPS 端口上的明文 HTTP 请求
+//
+// 用户输入 http://host:8443 时，浏览器只会显示无法理解的握手错误。
+// 这里在握手前检查第一个字节，不是 TLS 握手时直接返回 308 重定向。
+type sniffListener struct {
+	net.Listener
+	httpsPort string
+}
+
+// Accept 实现 net.Listener
+//
+// 检查推迟到连接第一次读取时进行，即在每个连接自己的 goroutine 中，不会阻塞 Accept。
+func (l *sniffListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &sniffConn{Conn: conn, httpsPort: l.httpsPort}, nil
+}
+
+// sniffConn 在第一次读取时判断连接是否为明文 HTTP
+type sniffConn struct {
+	net.Conn
+	httpsPort string
+	br        *bufio.Reader
+}
+
+// Read 实现 net.Conn
+func (c *sniffConn) Read(p []byte) (int, error) {
+	if c.br == nil {
+		c.br = bufio.NewReader(c.Conn)
+		first, err := c.br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if first[0] != tlsRecordTypeHandshake {
+			c.redirectPlaintext()
+			return 0, errPlaintextRedirected
+		}
+	}
+	return c.br.Read(p)
+}
+
+// redirectPlaintext 读取明文请求并返回重定向响应
+func (c *sniffConn) redirectPlaintext() {
+	req, err := http.ReadRequest(bufio.NewReader(io.LimitReader(c.br, plaintextHeaderLimit)))
+	if err != nil {
+		return
+	}
+
+	res := &http.Response{
+		StatusCode: http.StatusPermanentRedirect,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Location":   {httpsRedirectURL(req, c.httpsPort)},
+			"Connection": {"close"},
+		},
+		Close: true,
+	}
+	_ = res.Write(c.Conn)
+}
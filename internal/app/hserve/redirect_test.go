@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newSniffServer 与 Run 一样通过 sniffListener 提供 HTTPS 服务，返回监听地址与信任证书的证书池
+func newSniffServer(t *testing.T, httpsPort string) (string, *x509.CertPool) {
+	t.Helper()
+	cert, pool := newTestTLSCertificate(t, "localhost")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, r.URL.RequestURI())
+		}),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		ErrorLog:  log.New(io.Discard, "", 0),
+	}
+	go srv.ServeTLS(&sniffListener{Listener: ln, httpsPort: httpsPort}, "", "")
+	t.Cleanup(func() { srv.Close() })
+
+	return ln.Addr().String(), pool
+}
+
+// TestSniffListenerRedirectsPlaintext HTTPS 端口上的明文请求收到保留路径与查询参数的 308
+func TestSniffListenerRedirectsPlaintext(t *testing.T) {
+	tests := []struct {
+		httpsPort string
+		host      string
+		location  string
+	}{
+		{"8443", "example.lan:8443", "https://example.lan:8443/a?b"},
+		{"443", "example.lan", "https://example.lan/a?b"},
+		{"8443", "[::1]:8443", "https://[::1]:8443/a?b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			addr, _ := newSniffServer(t, tt.httpsPort)
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			if _, err := fmt.Fprintf(conn, "GET /a?b HTTP/1.1\r\nHost: %s\r\n\r\n", tt.host); err != nil {
+				t.Fatal(err)
+			}
+			res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != http.StatusPermanentRedirect {
+				t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusPermanentRedirect)
+			}
+			if got := res.Header.Get("Location"); got != tt.location {
+				t.Fatalf("Location = %q, want %q", got, tt.location)
+			}
+			if !res.Close {
+				t.Fatal("redirect response does not close the connection")
+			}
+		})
+	}
+}
+
+// TestSniffListenerPassesTLS 正常的 TLS 握手与请求不受 sniffListener 影响
+func TestSniffListenerPassesTLS(t *testing.T) {
+	addr, pool := newSniffServer(t, "8443")
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, ServerName: "localhost"}},
+		Timeout:   5 * time.Second,
+	}
+	defer client.CloseIdleConnections()
+
+	res, err := client.Get("https://" + addr + "/a?b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if string(body) != "/a?b" {
+		t.Fatalf("body = %q, want %q", body, "/a?b")
+	}
+	if res.TLS == nil || !res.TLS.HandshakeComplete {
+		t.Fatal("TLS handshake did not complete")
+	}
+}
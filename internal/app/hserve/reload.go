@@ -0,0 +1,235 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Alhkxsj/hserve/pkg/certgen"
+)
+
+// DefaultCertWatchInterval 默认的证书文件检查间隔
+const DefaultCertWatchInterval = 30 * time.Second
+
+// renewCheckInterval 检查证书是否需要自动续期的间隔，与 -cert-watch 无关
+const renewCheckInterval = time.Hour
+
+// CertReloader 监视证书文件，在文件变化时热加载证书
+//
+// 通过 tls.Config.GetCertificate 提供证书，新连接会立即使用新证书，
+// 已建立的连接不受影响。
+type CertReloader struct {
+	certPath string
+	keyPath  string
+	quiet    bool
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	certMod fileStamp
+	keyMod  fileStamp
+
+	lastErr string
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// fileStamp 用于判断文件是否变化
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewCertReloader 加载证书并创建热加载器
+func NewCertReloader(certPath, keyPath string, quiet bool) (*CertReloader, error) {
+	r := &CertReloader{
+		certPath: certPath,
+		keyPath:  keyPath,
+		quiet:    quiet,
+		stop:     make(chan struct{}),
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 实现 tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Leaf 返回当前使用的服务器证书
+func (r *CertReloader) Leaf() *x509.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert.Leaf
+}
+
+// Start 开始定期检查证书文件
+//
+// interval 大于 0 时按该间隔检查文件变化；renewWithin 大于 0 时启动时与之后每小时检查一次，
+// 证书剩余有效期不足该天数会使用已存储的 CA 自动续期。两者互不依赖，-cert-watch 0 时同样会续期。
+func (r *CertReloader) Start(interval time.Duration, renewWithin int) {
+	if interval <= 0 && renewWithin <= 0 {
+		return
+	}
+
+	go func() {
+		var watch, renew <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			watch = ticker.C
+		}
+		if renewWithin > 0 {
+			r.renewIfNeeded(renewWithin)
+			ticker := time.NewTicker(renewCheckInterval)
+			defer ticker.Stop()
+			renew = ticker.C
+		}
+
+		for {
+			select {
+			case <-watch:
+				r.reloadIfChanged()
+			case <-renew:
+				r.renewIfNeeded(renewWithin)
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止检查证书文件
+func (r *CertReloader) Stop() {
+	r.once.Do(func() { close(r.stop) })
+}
+
+// reloadIfChanged 文件变化时重新加载证书，失败时继续使用旧证书
+func (r *CertReloader) reloadIfChanged() {
+	certMod, keyMod, err := r.stat()
+	if err != nil {
+		r.reportError("检查证书文件失败", err)
+		return
+	}
+
+	r.mu.RLock()
+	changed := certMod != r.certMod || keyMod != r.keyMod
+	r.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	// 证书与私钥可能尚未全部写完，加载失败时等待下一次检查
+	if err := r.reload(); err != nil {
+		r.reportError("重新加载证书失败，继续使用旧证书", err)
+		return
+	}
+
+	r.lastErr = ""
+	if !r.quiet {
+		fmt.Printf("[%s] 🔄 已重新加载证书（有效期至 %s）\n",
+			time.Now().Format("15:04:05"),
+			r.Leaf().NotAfter.Local().Format("2006-01-02"))
+	}
+}
+
+// reload 加载证书与私钥并记录文件状态
+func (r *CertReloader) reload() error {
+	certMod, keyMod, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := loadCertificate(r.certPath, r.keyPath)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf == nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("解析 TLS 证书失败: %w", err)
+		}
+		cert.Leaf = leaf
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.certMod = certMod
+	r.keyMod = keyMod
+	r.mu.Unlock()
+	return nil
+}
+
+// stat 获取证书与私钥文件状态
+func (r *CertReloader) stat() (fileStamp, fileStamp, error) {
+	certMod, err := statFile(r.certPath)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, err
+	}
+	keyMod, err := statFile(r.keyPath)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, err
+	}
+	return certMod, keyMod, nil
+}
+
+// statFile 获取单个文件的修改时间与大小
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// renewIfNeeded 证书临近过期时使用已存储的 CA 续期，并立即加载新证书
+func (r *CertReloader) renewIfNeeded(within int) {
+	if !certgen.NeedsRenewal(r.Leaf(), within, time.Now()) {
+		return
+	}
+
+	renewed, err := certgen.RenewAt(r.certPath, r.keyPath, certgen.RenewOptions{Within: within})
+	if err != nil {
+		r.reportError("自动续期证书失败", err)
+		return
+	}
+	if !renewed {
+		return
+	}
+
+	if !r.quiet {
+		fmt.Printf("[%s] ♻️  证书即将过期，已使用已存储的 CA 自动续期\n", time.Now().Format("15:04:05"))
+	}
+	r.reloadIfChanged()
+}
+
+// renewExpiredCertificate 启动前由热加载器续期已过期的证书
+//
+// 续期失败（例如证书不是由 hserve CA 签发）时只输出警告，由预检查报告过期错误。
+func renewExpiredCertificate(opt Options) {
+	r, err := NewCertReloader(opt.CertPath, opt.KeyPath, opt.Quiet)
+	if err != nil {
+		return
+	}
+	if time.Now().After(r.Leaf().NotAfter) {
+		r.renewIfNeeded(opt.AutoRenewDays)
+	}
+}
+
+// reportError 输出错误，相同的错误只输出一次
+func (r *CertReloader) reportError(msg string, err error) {
+	text := fmt.Sprintf("%s: %v", msg, err)
+	if text == r.lastErr {
+		return
+	}
+	r.lastErr = text
+	fmt.Fprintf(os.Stderr, "[%s] ⚠️  %s\n", time.Now().Format("15:04:05"), text)
+}
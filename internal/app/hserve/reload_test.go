@@ -0,0 +1,240 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Alhkxsj/hserve/pkg/certgen"
+)
+
+// newTestCertificate 在临时 HOME 中使用 hserve CA 签发服务器证书，返回证书与私钥路径
+func newTestCertificate(t *testing.T, days int) (string, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PREFIX", "")
+	t.Setenv("TERMUX_VERSION", "")
+
+	if err := certgen.Generate(certgen.Options{SANs: []string{"localhost"}, Days: days}); err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := certgen.GetCertPaths()
+	return certPath, keyPath
+}
+
+// reissueTestCertificate 重新签发证书，并把文件时间推后以确保检查能发现变化
+func reissueTestCertificate(t *testing.T, days int) {
+	t.Helper()
+	if err := certgen.Generate(certgen.Options{Force: true, SANs: []string{"localhost"}, Days: days}); err != nil {
+		t.Fatal(err)
+	}
+	touchCertificateFiles(t)
+}
+
+// touchCertificateFiles 把证书与私钥的修改时间推后一分钟
+func touchCertificateFiles(t *testing.T) {
+	t.Helper()
+	certPath, keyPath := certgen.GetCertPaths()
+	future := time.Now().Add(time.Minute)
+	for _, p := range []string{certPath, keyPath} {
+		if err := os.Chtimes(p, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// expireTestCertificate 用 hserve CA 把现有服务器证书重新签发为已过期
+func expireTestCertificate(t *testing.T) {
+	t.Helper()
+	certPath, keyPath := certgen.GetCertPaths()
+	ca, err := tls.LoadX509KeyPair(certgen.GetCACertPath(), certgen.GetCAKeyPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := *leaf.Leaf
+	template.NotBefore = time.Now().Add(-48 * time.Hour)
+	template.NotAfter = time.Now().Add(-24 * time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, &template, ca.Leaf, leaf.Leaf.PublicKey, ca.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertReloaderReloadIfChanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, certPath, keyPath string)
+		reload bool // 是否应换用新证书
+	}{
+		{"unchanged", func(t *testing.T, certPath, keyPath string) {}, false},
+		{"new pair", func(t *testing.T, certPath, keyPath string) {
+			reissueTestCertificate(t, 30)
+		}, true},
+		{"mismatched key", func(t *testing.T, certPath, keyPath string) {
+			oldKey, _ := os.ReadFile(keyPath)
+			reissueTestCertificate(t, 30)
+			if err := os.WriteFile(keyPath, oldKey, 0600); err != nil {
+				t.Fatal(err)
+			}
+			touchCertificateFiles(t)
+		}, false},
+		{"corrupt certificate", func(t *testing.T, certPath, keyPath string) {
+			if err := os.WriteFile(certPath, []byte("not a certificate"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			touchCertificateFiles(t)
+		}, false},
+		{"missing key", func(t *testing.T, certPath, keyPath string) {
+			if err := os.Remove(keyPath); err != nil {
+				t.Fatal(err)
+			}
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPath, keyPath := newTestCertificate(t, 30)
+			r, err := NewCertReloader(certPath, keyPath, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			old := r.Leaf()
+
+			tt.change(t, certPath, keyPath)
+			r.reloadIfChanged()
+
+			if reloaded := !r.Leaf().Equal(old); reloaded != tt.reload {
+				t.Fatalf("reloaded = %v, want %v", reloaded, tt.reload)
+			}
+			if cert, _ := r.GetCertificate(nil); cert == nil || cert.Leaf != r.Leaf() {
+				t.Fatal("GetCertificate does not return the current certificate")
+			}
+		})
+	}
+}
+
+func TestCertReloaderRecoversAfterBadPair(t *testing.T) {
+	certPath, keyPath := newTestCertificate(t, 30)
+	r, err := NewCertReloader(certPath, keyPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := r.Leaf()
+
+	valid, _ := os.ReadFile(certPath)
+	if err := os.WriteFile(certPath, valid[:len(valid)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	touchCertificateFiles(t)
+	r.reloadIfChanged()
+	if !r.Leaf().Equal(old) {
+		t.Fatal("half-written certificate replaced the old one")
+	}
+
+	// 写完后下一次检查加载新证书
+	reissueTestCertificate(t, 30)
+	r.reloadIfChanged()
+	if r.Leaf().Equal(old) {
+		t.Fatal("certificate not reloaded after the pair became valid")
+	}
+}
+
+func TestCertReloaderRenewIfNeeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		days   int // 现有证书有效天数
+		within int
+		renew  bool
+	}{
+		{"expiring", 10, 30, true},
+		{"not yet due", 60, 30, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPath, keyPath := newTestCertificate(t, tt.days)
+			r, err := NewCertReloader(certPath, keyPath, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			old := r.Leaf()
+
+			r.renewIfNeeded(tt.within)
+
+			if renewed := !r.Leaf().Equal(old); renewed != tt.renew {
+				t.Fatalf("renewed = %v, want %v", renewed, tt.renew)
+			}
+			if tt.renew {
+				wantAfter := time.Now().Add(time.Duration(certgen.DefaultLeafDays-1) * 24 * time.Hour)
+				if r.Leaf().NotAfter.Before(wantAfter) {
+					t.Fatalf("renewed certificate expires %v, want about %d days", r.Leaf().NotAfter, certgen.DefaultLeafDays)
+				}
+			}
+		})
+	}
+}
+
+// TestCertReloaderStartRenewsWithoutWatch -cert-watch 0 时仍会自动续期
+func TestCertReloaderStartRenewsWithoutWatch(t *testing.T) {
+	certPath, keyPath := newTestCertificate(t, 10)
+	r, err := NewCertReloader(certPath, keyPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := r.Leaf()
+
+	r.Start(0, 30)
+	defer r.Stop()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for r.Leaf().Equal(old) {
+		if time.Now().After(deadline) {
+			t.Fatal("certificate was not renewed with -cert-watch 0")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRenewExpiredCertificate 开启自动续期时，已过期的证书在预检查前续期
+func TestRenewExpiredCertificate(t *testing.T) {
+	tests := []struct {
+		name      string
+		autoRenew int
+		healthy   bool
+	}{
+		{"auto renew", 30, true},
+		{"no auto renew", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPath, keyPath := newTestCertificate(t, 30)
+			expireTestCertificate(t)
+			if err := checkCertificateHealth(certPath, keyPath, "", true); err == nil {
+				t.Fatal("expired certificate passed the health check")
+			}
+
+			opt := Options{CertPath: certPath, KeyPath: keyPath, AutoRenewDays: tt.autoRenew, Quiet: true}
+			if opt.AutoRenewDays > 0 {
+				renewExpiredCertificate(opt)
+			}
+
+			err := checkCertificateHealth(certPath, keyPath, "", true)
+			if healthy := err == nil; healthy != tt.healthy {
+				t.Fatalf("healthy = %v (%v), want %v", healthy, err, tt.healthy)
+			}
+		})
+	}
+}
@@ -0,0 +1,585 @@
+package server
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// tus 可续传上传协议（https://tus.io/protocols/resumable-upload）
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination,checksum"
+	tusChunkType  = "application/offset+octet-stream"
+)
+
+// tusChecksumAlgorithms checksum 扩展支持的算法
+var tusChecksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// statusChecksumMismatch tus 协议规定的校验和不匹配状态码
+const statusChecksumMismatch = 460
+
+// errChecksumMismatch 数据块与 Upload-Checksum 不符
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// resumablePathPrefix 可续传上传资源的地址前缀
+//
+// 以 . 开头的路径不会被当作共享文件访问，因此不会与共享目录中的文件冲突。
+const resumablePathPrefix = "/.hserve/uploads/"
+
+// resumableExpiry 可续传上传在最后一次收到数据后保留的时间
+const resumableExpiry = 24 * time.Hour
+
+// resumableCleanupInterval 清理过期上传的间隔
+const resumableCleanupInterval = time.Hour
+
+// resumableOrphanGrace 没有元数据的数据文件保留多久后视为崩溃残留
+const resumableOrphanGrace = time.Minute
+
+// UploadStaging 可续传上传的暂存区
+//
+// 每个上传对应暂存目录中的两个文件：<id>.json 记录目标位置与总大小，
+// <id>.part 保存已收到的数据，其大小即当前偏移量，服务器重启后可以继续上传。
+type UploadStaging struct {
+	dir     string
+	maxSize int64 // 单个上传的最大大小，0 表示不限制
+
+	mu     sync.Mutex
+	active map[string]bool // 正在写入的上传
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// resumableInfo 可续传上传的元数据
+type resumableInfo struct {
+	Root    string    `json:"root"` // 创建上传时的共享目录，用于区分虚拟主机
+	Dir     string    `json:"dir"`  // 目标目录（URL 路径）
+	Name    string    `json:"name"`
+	Length  int64     `json:"length"`
+	Created time.Time `json:"created"`
+}
+
+// NewUploadStaging 创建可续传上传的暂存区
+func NewUploadStaging(dir string, maxSize int64) (*UploadStaging, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建上传暂存目录失败: %w", err)
+	}
+	return &UploadStaging{
+		dir:     dir,
+		maxSize: maxSize,
+		active:  make(map[string]bool),
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// Start 开始定期清理过期的上传
+func (s *UploadStaging) Start() {
+	go func() {
+		ticker := time.NewTicker(resumableCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			s.cleanup()
+
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止清理
+func (s *UploadStaging) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// cleanup 删除过期的上传，以及崩溃后留下的没有元数据的数据文件
+func (s *UploadStaging) cleanup() {
+	infos, _ := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	for _, infoPath := range infos {
+		id := strings.TrimSuffix(filepath.Base(infoPath), ".json")
+		if !s.lock(id) {
+			continue
+		}
+		if _, _, expires, err := s.stat(id); err != nil || time.Now().After(expires) {
+			s.remove(id)
+		}
+		s.unlock(id)
+	}
+
+	// create 先写数据文件再写元数据，刚创建的上传短时间内也没有元数据
+	parts, _ := filepath.Glob(filepath.Join(s.dir, "*.part"))
+	for _, dataPath := range parts {
+		infoPath := strings.TrimSuffix(dataPath, ".part") + ".json"
+		if _, err := os.Stat(infoPath); !os.IsNotExist(err) {
+			continue
+		}
+		if part, err := os.Stat(dataPath); err == nil && time.Since(part.ModTime()) > resumableOrphanGrace {
+			_ = os.Remove(dataPath)
+		}
+	}
+}
+
+// paths 返回上传的元数据文件与数据文件路径
+func (s *UploadStaging) paths(id string) (string, string) {
+	return filepath.Join(s.dir, id+".json"), filepath.Join(s.dir, id+".part")
+}
+
+// create 创建新的上传
+func (s *UploadStaging) create(info resumableInfo) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+	infoPath, dataPath := s.paths(id)
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(dataPath, nil, 0600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(infoPath, data, 0600); err != nil {
+		_ = os.Remove(dataPath)
+		return "", err
+	}
+	return id, nil
+}
+
+// stat 读取上传的元数据、当前偏移量与过期时间
+func (s *UploadStaging) stat(id string) (*resumableInfo, int64, time.Time, error) {
+	infoPath, dataPath := s.paths(id)
+
+	data, err := os.ReadFile(infoPath)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+	var info resumableInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, 0, time.Time{}, err
+	}
+
+	part, err := os.Stat(dataPath)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+	return &info, part.Size(), part.ModTime().Add(resumableExpiry), nil
+}
+
+// lookup 查找属于 root 且未过期的上传
+func (s *UploadStaging) lookup(id, root string) (*resumableInfo, int64, time.Time, bool) {
+	if !isValidUploadID(id) {
+		return nil, 0, time.Time{}, false
+	}
+	info, offset, expires, err := s.stat(id)
+	if err != nil || info.Root != root || time.Now().After(expires) {
+		return nil, 0, time.Time{}, false
+	}
+	return info, offset, expires, true
+}
+
+// lock 标记上传正在写入，同一上传同时只允许一个 PATCH 请求
+func (s *UploadStaging) lock(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[id] {
+		return false
+	}
+	s.active[id] = true
+	return true
+}
+
+// unlock 清除写入标记
+func (s *UploadStaging) unlock(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, id)
+}
+
+// remove 删除上传的暂存文件
+func (s *UploadStaging) remove(id string) {
+	infoPath, dataPath := s.paths(id)
+	_ = os.Remove(dataPath)
+	_ = os.Remove(infoPath)
+}
+
+// isValidUploadID 检查上传 ID 格式，避免拼接出暂存目录之外的路径
+func isValidUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// isResumableUploadRequest 检查是否为 tus 协议请求
+//
+// 创建上传是携带 Tus-Resumable 头、发往目标目录的 POST 请求，
+// 之后的 HEAD / PATCH / DELETE 发往创建时返回的地址。
+func isResumableUploadRequest(r *http.Request) bool {
+	switch {
+	case strings.HasPrefix(r.URL.Path, resumablePathPrefix):
+		return true
+	case r.Method == http.MethodPost:
+		return r.Header.Get("Tus-Resumable") != ""
+	case r.Method == http.MethodOptions:
+		return strings.HasSuffix(r.URL.Path, "/")
+	}
+	return false
+}
+
+// isResumableChunkRequest 检查是否为上传数据的 PATCH 请求
+//
+// 可续传上传的大小由 Upload-Length 限制，不受 -max-body-bytes 限制。
+func isResumableChunkRequest(r *http.Request) bool {
+	return r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, resumablePathPrefix)
+}
+
+// handleResumableUpload 处理 tus 协议请求，返回 false 表示不是 tus 请求
+func handleResumableUpload(w http.ResponseWriter, r *http.Request, opt HandlerOptions) bool {
+	if opt.Staging == nil || !isResumableUploadRequest(r) {
+		return false
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if r.Method == http.MethodOptions {
+		setTusCapabilities(w, opt.Staging)
+		// WebDAV 模式下由 WebDAV 处理器补充 DAV 与 Allow 头并发送响应
+		if opt.WebDAV {
+			return false
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		http.Error(w, "Unsupported tus version", http.StatusPreconditionFailed)
+		return true
+	}
+
+	if !strings.HasPrefix(r.URL.Path, resumablePathPrefix) {
+		createResumableUpload(w, r, opt)
+		return true
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, resumablePathPrefix)
+	switch r.Method {
+	case http.MethodHead:
+		headResumableUpload(w, r, id, opt)
+	case http.MethodPatch:
+		patchResumableUpload(w, r, id, opt)
+	case http.MethodDelete:
+		deleteResumableUpload(w, r, id, opt)
+	default:
+		w.Header().Set("Allow", "HEAD, PATCH, DELETE")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+	return true
+}
+
+// setTusCapabilities 设置 OPTIONS 响应头，说明支持的协议版本与扩展
+func setTusCapabilities(w http.ResponseWriter, s *UploadStaging) {
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Checksum-Algorithm", "md5,sha1,sha256")
+	if s.maxSize > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(s.maxSize, 10))
+	}
+}
+
+// createResumableUpload 在请求的目录中创建上传，文件名来自 Upload-Metadata 的 filename
+func createResumableUpload(w http.ResponseWriter, r *http.Request, opt HandlerOptions) {
+	urlDir := path.Clean("/" + r.URL.Path)
+	if !isRequestAllowed(urlDir, opt.Root, opt.Paths, len(opt.Paths) > 0) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	dir, ok := uploadDir(w, urlDir, opt)
+	if !ok {
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "Invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	if opt.Staging.maxSize > 0 && length > opt.Staging.maxSize {
+		sendTooLargeResponse(w)
+		return
+	}
+
+	metadata := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	name := metadata["filename"]
+	if name == "" {
+		name = metadata["name"]
+	}
+	if !isValidUploadName(name) ||
+		!isRequestAllowed(path.Join(urlDir, name), opt.Root, opt.Paths, len(opt.Paths) > 0) {
+		http.Error(w, "Invalid file name", http.StatusBadRequest)
+		return
+	}
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil && !opt.AllowOverwrite {
+		http.Error(w, "File already exists", http.StatusConflict)
+		return
+	}
+
+	info := resumableInfo{Root: opt.Root, Dir: urlDir, Name: name, Length: length, Created: time.Now()}
+	id, err := opt.Staging.create(info)
+	if err != nil {
+		http.Error(w, "Upload failed", http.StatusInternalServerError)
+		return
+	}
+
+	// 空文件无需后续 PATCH，直接完成；失败时客户端拿不到上传地址，不保留暂存文件
+	if length == 0 {
+		if err := finishResumableUpload(opt.Staging, id, &info, opt.AllowOverwrite); err != nil {
+			opt.Staging.remove(id)
+			sendUploadError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Location", resumablePathPrefix+id)
+	w.Header().Set("Upload-Expires", time.Now().Add(resumableExpiry).UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// headResumableUpload 返回上传的当前偏移量，客户端据此继续上传
+func headResumableUpload(w http.ResponseWriter, r *http.Request, id string, opt HandlerOptions) {
+	info, offset, expires, ok := opt.Staging.lookup(id, opt.Root)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+	w.Header().Set("Upload-Expires", expires.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+}
+
+// patchResumableUpload 从 Upload-Offset 处追加数据，收齐后移动到目标位置
+//
+// 连接中断时已写入的数据会保留，客户端通过 HEAD 取得偏移量后继续。
+func patchResumableUpload(w http.ResponseWriter, r *http.Request, id string, opt HandlerOptions) {
+	if r.Header.Get("Content-Type") != tusChunkType {
+		http.Error(w, "Content-Type must be "+tusChunkType, http.StatusUnsupportedMediaType)
+		return
+	}
+	clientOffset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || clientOffset < 0 {
+		http.Error(w, "Invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	sum, err := parseUploadChecksum(r.Header.Get("Upload-Checksum"))
+	if err != nil {
+		http.Error(w, "Invalid Upload-Checksum", http.StatusBadRequest)
+		return
+	}
+
+	if !opt.Staging.lock(id) {
+		http.Error(w, "Upload is in progress", http.StatusLocked)
+		return
+	}
+	defer opt.Staging.unlock(id)
+
+	info, offset, _, ok := opt.Staging.lookup(id, opt.Root)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if clientOffset != offset {
+		http.Error(w, "Upload-Offset mismatch", http.StatusConflict)
+		return
+	}
+
+	offset, err = appendChunk(opt.Staging, id, uploadBody(w, r.Body), offset, info.Length-offset, sum)
+	if errors.Is(err, errChecksumMismatch) {
+		http.Error(w, "Checksum Mismatch", statusChecksumMismatch)
+		return
+	}
+	if err != nil {
+		sendUploadError(w, err)
+		return
+	}
+
+	if offset == info.Length {
+		if err := finishResumableUpload(opt.Staging, id, info, opt.AllowOverwrite); err != nil {
+			sendUploadError(w, err)
+			return
+		}
+	} else {
+		w.Header().Set("Upload-Expires", time.Now().Add(resumableExpiry).UTC().Format(http.TimeFormat))
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// appendChunk 将请求体追加到数据文件，最多写入 remaining 字节，返回新的偏移量
+//
+// 没有校验和时，即使中途出错已收到的数据也落盘保存；
+// 带校验和的数据块必须完整且校验通过，否则整块丢弃，偏移量回到 offset。
+func appendChunk(s *UploadStaging, id string, body io.Reader, offset, remaining int64, sum *chunkChecksum) (int64, error) {
+	_, dataPath := s.paths(id)
+	f, err := os.OpenFile(dataPath, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	var reader io.Reader = io.LimitReader(body, remaining)
+	if sum != nil {
+		reader = io.TeeReader(reader, sum.hash)
+	}
+	_, copyErr := io.Copy(f, reader)
+	if sum != nil && (copyErr != nil || !sum.matches()) {
+		if copyErr == nil {
+			copyErr = errChecksumMismatch
+		}
+		if err := f.Truncate(offset); err != nil {
+			copyErr = errors.Join(copyErr, err)
+		}
+	}
+	syncErr := f.Sync()
+	closeErr := f.Close()
+
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := errors.Join(copyErr, syncErr, closeErr); err != nil {
+		return info.Size(), err
+	}
+	return info.Size(), nil
+}
+
+// finishResumableUpload 将收齐的数据移动到目标位置并删除暂存文件
+//
+// 失败时（例如目标文件已被他人创建）保留暂存数据：客户端可以在处理冲突后
+// 以 Upload-Offset 等于总大小、不带数据的 PATCH 重试，或用 DELETE 放弃，超时未处理的由 cleanup 删除。
+func finishResumableUpload(s *UploadStaging, id string, info *resumableInfo, overwrite bool) error {
+	// 创建上传后目录可能已被替换为符号链接，移动前重新检查
+	target := path.Join(info.Dir, info.Name)
+	if !isPathSafe(info.Dir, info.Root) || !isPathSafe(target, info.Root) {
+		return errInvalidUploadName
+	}
+	dir := filepath.Join(info.Root, filepath.FromSlash(info.Dir))
+
+	_, dataPath := s.paths(id)
+	if err := os.Chmod(dataPath, 0644); err != nil {
+		return err
+	}
+
+	err := commitUpload(dataPath, filepath.Join(dir, info.Name), overwrite)
+	if errors.Is(err, syscall.EXDEV) {
+		// 暂存目录与共享目录不在同一文件系统（例如 Termux 的 /sdcard），改为复制
+		err = copyUpload(dataPath, dir, info.Name, overwrite)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.remove(id)
+	return nil
+}
+
+// copyUpload 将暂存数据复制到目标目录
+func copyUpload(dataPath, dir, name string, overwrite bool) error {
+	f, err := os.Open(dataPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return saveUpload(dir, name, f, overwrite)
+}
+
+// chunkChecksum PATCH 请求携带的数据块校验和
+type chunkChecksum struct {
+	hash hash.Hash
+	want []byte
+}
+
+// matches 检查已读取数据的校验和
+func (c *chunkChecksum) matches() bool {
+	return bytes.Equal(c.hash.Sum(nil), c.want)
+}
+
+// parseUploadChecksum 解析 Upload-Checksum 头：“算法 Base64摘要”，没有该头时返回 nil
+func parseUploadChecksum(header string) (*chunkChecksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, _ := strings.Cut(header, " ")
+	newHash, ok := tusChecksumAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+	want, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &chunkChecksum{hash: newHash(), want: want}, nil
+}
+
+// deleteResumableUpload 取消上传并删除暂存数据
+func deleteResumableUpload(w http.ResponseWriter, r *http.Request, id string, opt HandlerOptions) {
+	if !opt.Staging.lock(id) {
+		http.Error(w, "Upload is in progress", http.StatusLocked)
+		return
+	}
+	defer opt.Staging.unlock(id)
+
+	if _, _, _, ok := opt.Staging.lookup(id, opt.Root); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	opt.Staging.remove(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseUploadMetadata 解析 Upload-Metadata 头：逗号分隔的 “键 Base64值”
+func parseUploadMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(value)
+	}
+	return metadata
+}
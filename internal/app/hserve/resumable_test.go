@@ -0,0 +1,237 @@
+package server
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumableUploadResume(t *testing.T) {
+	tt := newTusTest(t, 0, false)
+	loc := tt.create("video.mp4", 10)
+
+	if rec := tt.patch(loc, 0, "hello", nil); rec.Code != http.StatusNoContent || rec.Header().Get("Upload-Offset") != "5" {
+		t.Fatalf("first patch = %d offset %s", rec.Code, rec.Header().Get("Upload-Offset"))
+	}
+	if _, err := os.Stat(filepath.Join(tt.root, "video.mp4")); !os.IsNotExist(err) {
+		t.Fatal("incomplete upload visible in share")
+	}
+
+	// 模拟断线重连：先查询偏移量再继续
+	if code, off := tt.offset(loc); code != http.StatusOK || off != 5 {
+		t.Fatalf("HEAD = %d offset %d, want 200 offset 5", code, off)
+	}
+	if rec := tt.patch(loc, 5, "world", nil); rec.Code != http.StatusNoContent || rec.Header().Get("Upload-Offset") != "10" {
+		t.Fatalf("second patch = %d offset %s", rec.Code, rec.Header().Get("Upload-Offset"))
+	}
+
+	if got := tt.readFile("video.mp4"); got != "helloworld" {
+		t.Fatalf("content = %q", got)
+	}
+	if code, _ := tt.offset(loc); code != http.StatusNotFound {
+		t.Fatalf("HEAD after completion = %d, want 404", code)
+	}
+}
+
+func TestResumableUploadPatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  int
+		data    string
+		headers map[string]string
+		want    int
+	}{
+		{"offset ahead", 3, "x", nil, http.StatusConflict},
+		{"wrong content type", 0, "x", map[string]string{"Content-Type": "application/octet-stream"}, http.StatusUnsupportedMediaType},
+		{"unsupported checksum", 0, "x", map[string]string{"Upload-Checksum": "crc32 AAAA"}, http.StatusBadRequest},
+		{"checksum mismatch", 0, "abc", map[string]string{"Upload-Checksum": "sha1 " + sha1Base64("abd")}, statusChecksumMismatch},
+		{"wrong version", 0, "x", map[string]string{"Tus-Resumable": "0.2.2"}, http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTusTest(t, 0, false)
+			loc := tt.create("a.bin", 6)
+
+			if rec := tt.patch(loc, tc.offset, tc.data, tc.headers); rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+			// 被拒绝的数据块不改变偏移量
+			if _, off := tt.offset(loc); off != 0 {
+				t.Fatalf("offset = %d, want 0", off)
+			}
+		})
+	}
+}
+
+func TestResumableUploadChecksum(t *testing.T) {
+	tt := newTusTest(t, 0, false)
+	loc := tt.create("a.txt", 6)
+
+	rec := tt.patch(loc, 0, "abc", map[string]string{"Upload-Checksum": "sha1 " + sha1Base64("abc")})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	rec = tt.patch(loc, 3, "xyz", map[string]string{"Upload-Checksum": "sha1 " + sha1Base64("def")})
+	if rec.Code != statusChecksumMismatch {
+		t.Fatalf("status = %d, want 460", rec.Code)
+	}
+	if _, off := tt.offset(loc); off != 3 {
+		t.Fatalf("offset after mismatch = %d, want 3", off)
+	}
+	if rec := tt.patch(loc, 3, "def", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	if got := tt.readFile("a.txt"); got != "abcdef" {
+		t.Fatalf("content = %q", got)
+	}
+}
+
+func TestResumableUploadCreate(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		length   string
+		filename string
+		want     int
+	}{
+		{"ok", "/", "3", "a.txt", http.StatusCreated},
+		{"missing length", "/", "", "a.txt", http.StatusBadRequest},
+		{"too large", "/", "101", "a.txt", http.StatusRequestEntityTooLarge},
+		{"hidden name", "/", "3", ".env", http.StatusBadRequest},
+		{"path in name", "/", "3", "../a.txt", http.StatusBadRequest},
+		{"missing name", "/", "3", "", http.StatusBadRequest},
+		{"existing file", "/", "3", "exists.txt", http.StatusConflict},
+		{"missing dir", "/nodir/", "3", "a.txt", http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTusTest(t, 100, false)
+			_ = os.WriteFile(filepath.Join(tt.root, "exists.txt"), []byte("old"), 0644)
+
+			headers := map[string]string{"Upload-Length": tc.length}
+			if tc.filename != "" {
+				headers["Upload-Metadata"] = "filename " + base64.StdEncoding.EncodeToString([]byte(tc.filename))
+			}
+			if rec := tt.do(http.MethodPost, tc.target, "", headers); rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestResumableUploadConflictKeepsData(t *testing.T) {
+	tt := newTusTest(t, 0, false)
+	loc := tt.create("a.txt", 3)
+
+	// 上传期间有人创建了同名文件
+	target := filepath.Join(tt.root, "a.txt")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if rec := tt.patch(loc, 0, "new", nil); rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+	if code, off := tt.offset(loc); code != http.StatusOK || off != 3 {
+		t.Fatalf("HEAD = %d offset %d, want 200 offset 3", code, off)
+	}
+
+	// 处理冲突后以空 PATCH 重试
+	_ = os.Remove(target)
+	if rec := tt.patch(loc, 3, "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("retry status = %d, want 204", rec.Code)
+	}
+	if got := tt.readFile("a.txt"); got != "new" {
+		t.Fatalf("content = %q", got)
+	}
+}
+
+func TestResumableUploadDelete(t *testing.T) {
+	tt := newTusTest(t, 0, false)
+	loc := tt.create("a.txt", 3)
+
+	if rec := tt.do(http.MethodDelete, loc, "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, want 204", rec.Code)
+	}
+	if code, _ := tt.offset(loc); code != http.StatusNotFound {
+		t.Fatalf("HEAD after DELETE = %d, want 404", code)
+	}
+	if entries, _ := os.ReadDir(tt.staging.dir); len(entries) != 0 {
+		t.Fatalf("staging not empty: %v", entries)
+	}
+}
+
+func TestResumableUploadExpiry(t *testing.T) {
+	tt := newTusTest(t, 0, false)
+	loc := tt.create("a.txt", 3)
+	id := strings.TrimPrefix(loc, resumablePathPrefix)
+	_, dataPath := tt.staging.paths(id)
+
+	old := time.Now().Add(-resumableExpiry - time.Minute)
+	if err := os.Chtimes(dataPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := tt.offset(loc); code != http.StatusNotFound {
+		t.Fatalf("HEAD on expired upload = %d, want 404", code)
+	}
+	if rec := tt.patch(loc, 0, "abc", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("PATCH on expired upload = %d, want 404", rec.Code)
+	}
+
+	tt.staging.cleanup()
+	if entries, _ := os.ReadDir(tt.staging.dir); len(entries) != 0 {
+		t.Fatalf("expired upload not removed: %v", entries)
+	}
+}
+
+func TestUploadStagingCleanupOrphans(t *testing.T) {
+	tt := newTusTest(t, 0, false)
+	live := strings.TrimPrefix(tt.create("a.txt", 3), resumablePathPrefix)
+
+	orphan := filepath.Join(tt.staging.dir, strings.Repeat("ab", 16)+".part")
+	fresh := filepath.Join(tt.staging.dir, strings.Repeat("cd", 16)+".part")
+	for _, p := range []string{orphan, fresh} {
+		if err := os.WriteFile(p, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-resumableOrphanGrace - time.Minute)
+	_ = os.Chtimes(orphan, old, old)
+
+	tt.staging.cleanup()
+
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Fatal("orphaned part not removed")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatal("part of an upload being created was removed")
+	}
+	if _, _, _, ok := tt.staging.lookup(live, tt.root); !ok {
+		t.Fatal("active upload removed")
+	}
+}
+
+func TestParseUploadMetadata(t *testing.T) {
+	got := parseUploadMetadata("filename " + base64.StdEncoding.EncodeToString([]byte("视频 1.mp4")) + ",is_confidential, filetype dmlkZW8vbXA0,bad !!!")
+	want := map[string]string{"filename": "视频 1.mp4", "is_confidential": "", "filetype": "video/mp4"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// sha1Base64 返回 Upload-Checksum 使用的 Base64 SHA-1 摘要
+func sha1Base64(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/Alhkxsj/hserve/pkg/certgen"
+)
+
+// checkCertificateCoverage 检查本机网卡地址是否都包含在证书中
+//
+// 手机通过 Wi-Fi 访问时使用的是局域网 IP，证书未包含该 IP 时浏览器会报警告。
+// 标准输入为终端且未开启 -quiet 时提示使用已存储的 CA 重新签发证书，
+// 否则（后台运行、systemd、管道输入）只输出警告并继续启动，不会阻塞等待输入。
+func checkCertificateCoverage(opt Options) {
+	cert, err := readLeafCertificate(opt.CertPath)
+	if err != nil {
+		// 证书无法解析时交给 LoadTLSConfig 报告具体错误
+		return
+	}
+
+	missing := uncoveredLocalIPs(cert)
+	if len(missing) == 0 {
+		return
+	}
+
+	fmt.Println("⚠️  以下本机地址未包含在服务器证书中，通过这些地址访问时浏览器会提示不安全:")
+	for _, ip := range missing {
+		fmt.Printf("   - %s\n", ip)
+	}
+
+	if !canReissueCertificate(opt.CertPath) {
+		return
+	}
+
+	if opt.Quiet || !isInteractiveTerminal() {
+		fmt.Println("💡 可运行 hserve cert -force -auto-san 使用已存储的 CA 重新签发证书")
+		return
+	}
+
+	if !askYesNo("❓ 是否立即使用已存储的 CA 重新签发证书？设备上的 CA 无需重新安装 [y/N]: ") {
+		return
+	}
+
+	// 原私钥类型不支持生成时改用默认类型
+	keyType := certgen.KeyTypeOf(cert.PublicKey)
+	if !keyType.Supported() {
+		keyType = certgen.DefaultKeyType
+	}
+
+	reissueOpts := certgen.Options{
+		Force:   true,
+		SANs:    certgen.CertificateNames(cert),
+		AutoSAN: true,
+		KeyType: keyType,
+	}
+	if err := certgen.Generate(reissueOpts); err != nil {
+		fmt.Println("❌ 重新签发证书失败:", err)
+	}
+}
+
+// readLeafCertificate 读取 PEM 文件中的第一张证书
+func readLeafCertificate(certPath string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s 不是有效的 PEM 证书", certPath)
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// uncoveredLocalIPs 返回证书未覆盖的本机地址
+func uncoveredLocalIPs(cert *x509.Certificate) []net.IP {
+	ips, err := certgen.LocalIPs()
+	if err != nil {
+		return nil
+	}
+
+	var missing []net.IP
+	for _, ip := range ips {
+		if cert.VerifyHostname(ip.String()) != nil {
+			missing = append(missing, ip)
+		}
+	}
+	return missing
+}
+
+// canReissueCertificate 检查是否使用默认证书且存在可用于签发的 CA
+func canReissueCertificate(certPath string) bool {
+	defaultCertPath, _ := certgen.GetCertPaths()
+	return certPath == defaultCertPath && certgen.CheckCertificateExists(certgen.GetCAKeyPath())
+}
+
+// isInteractiveTerminal 检查标准输入是否为终端
+func isInteractiveTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// askYesNo 向用户提问，仅在输入 y / yes 时返回 true
+func askYesNo(prompt string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
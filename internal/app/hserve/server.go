@@ -4,11 +4,14 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	tlspolicy "github.com/Alhkxsj/hserve/internal/tls"
 )
 
 type Options struct {
@@ -17,6 +20,7 @@ type Options struct {
 	Quiet          bool
 	CertPath       string
 	KeyPath        string
+	CACertPath     string        // 用于验证服务器证书的 CA，为空时跳过证书链验证
 	Paths          []string      // 指定要分享的特定路径列表
 	ReadTimeout    time.Duration // 读取超时
 	WriteTimeout   time.Duration // 写入超时
@@ -26,46 +30,192 @@ type Options struct {
 	AuthUser       string        // 基本身份验证用户名
 	AuthPass       string        // 基本身份验证密码
 	AuthRealm      string        // 基本身份验证领域
+	CertWatch      time.Duration // 证书文件检查间隔，0 表示不检查
+	AutoRenewDays  int           // 证书剩余天数少于该值时自动续期，0 表示不续期
+	ClientCAPath   string        // 用于验证客户端证书的 CA，为空时不启用双向 TLS
+	ClientAuth     string        // 客户端证书验证模式：require | verify-if-given
+	OnboardPort    int           // 证书引导页（明文 HTTP）端口，0 表示不启用
+	QRCode         bool          // 启动时在终端显示访问地址二维码
+	TLSProfile     string        // TLS 策略档位：modern | intermediate | compat，为空时使用 intermediate
+	TLSMinVersion  string        // 覆盖策略的最低协议版本，例如 1.2
+	TLSCiphers     string        // 覆盖策略的密码套件（逗号分隔）
+	TLSCurves      string        // 覆盖策略的曲线偏好（逗号分隔）
+	TLSLog         bool          // 访问日志中附带 TLS 连接详情
+	VirtualHosts   []VirtualHost // 按主机名使用不同证书与共享目录，未匹配的请求使用默认设置
+	ACME           *ACMEOptions  // 通过 ACME 为指定主机名申请证书，为空时不启用
+	HTTPPort       int           // 明文 HTTP 重定向端口，0 表示不启用
+	HTTP3          bool          // 在同一端口号的 UDP 上提供 HTTP/3
+	DisableHTTP2   bool          // 关闭 HTTP/2，只协商 HTTP/1.1
+	H2MaxStreams   int           // HTTP/2 每个连接的最大并发流数，0 表示默认值
+	H2MaxFrameSize int           // HTTP/2 可接收的最大帧大小，0 表示默认值
+	H2StreamWindow int           // HTTP/2 每个流的接收窗口大小，0 表示默认值
+	Upload         bool          // 允许通过表单 POST 与 PUT 上传文件
+	AllowOverwrite bool          // 允许上传覆盖已有文件
+	UploadStaging  string        // 可续传上传的暂存目录，为空时不支持 tus 协议
+	UploadMaxSize  int64         // 可续传上传的最大文件大小，0 表示不限制
+	WebDAV         bool          // 通过 WebDAV 提供共享目录，修改文件还需要开启 Upload
 }
 
 // Run 启动 HTTPS 服务器
 func Run(opt Options) error {
+	// 校验 TLS 策略
+	policy, err := buildTLSPolicy(opt)
+	if err != nil {
+		return err
+	}
+
+	// 校验 HTTP/2 参数
+	if err := validateHTTP2Options(opt); err != nil {
+		return err
+	}
+
+	// 开启自动续期时先续期已过期的证书，否则预检查会拒绝启动
+	if opt.AutoRenewDays > 0 {
+		renewExpiredCertificate(opt)
+	}
+
 	// 预检查
-	if err := PreflightCheck(opt.Addr, opt.CertPath, opt.KeyPath); err != nil {
+	if err := PreflightCheck(opt.Addr, opt.CertPath, opt.KeyPath, opt.CACertPath, opt.Quiet); err != nil {
 		return err
 	}
 
+	// 检查证书是否覆盖本机地址
+	checkCertificateCoverage(opt)
+
 	// 加载 TLS 配置
-	tlsConfig, err := LoadTLSConfig(opt.CertPath, opt.KeyPath)
+	tlsConfig, reloader, err := LoadTLSConfig(opt.CertPath, opt.KeyPath, opt.Quiet, policy)
 	if err != nil {
 		return err
 	}
+	if err := configureClientAuth(tlsConfig, opt.ClientCAPath, opt.ClientAuth); err != nil {
+		return err
+	}
+	reloader.Start(opt.CertWatch, opt.AutoRenewDays)
+	defer reloader.Stop()
+
+	// 创建可续传上传的暂存区
+	var staging *UploadStaging
+	if opt.Upload && opt.UploadStaging != "" {
+		if staging, err = NewUploadStaging(opt.UploadStaging, opt.UploadMaxSize); err != nil {
+			return err
+		}
+		staging.Start()
+		defer staging.Stop()
+	}
+
+	// 加载虚拟主机
+	vhosts, err := newVirtualHosts(opt, staging)
+	if err != nil {
+		return err
+	}
+	tlsConfig.GetCertificate = vhosts.getCertificate(reloader.GetCertificate)
+	vhosts.start(opt.CertWatch, opt.AutoRenewDays)
+	defer vhosts.stop()
+
+	// 通过 ACME 为指定主机名申请证书
+	host, port, _ := net.SplitHostPort(opt.Addr)
+	var issuer *acmeIssuer
+	if opt.ACME != nil {
+		if issuer, err = newACMEIssuer(*opt.ACME); err != nil {
+			return err
+		}
+		issuer.install(tlsConfig)
+		if err := issuer.start(host, opt.HTTPPort); err != nil {
+			return err
+		}
+		defer issuer.stop()
+	}
 
 	// 创建请求处理器
-	handler := NewHandler(opt.Root, opt.Quiet, opt.Paths)
+	handler := NewHandler(handlerOptions(opt, staging))
 
 	// 应用中间件
 	handler = applyMiddleware(handler, opt)
 
+	// 按主机名分发到虚拟主机
+	handler = vhosts.route(handler)
+
 	// 创建 HTTP 服务器
 	srv := createHTTPServer(opt, handler, tlsConfig)
 
+	// 统计 TLS 握手成功与失败情况
+	stats := newTLSStats(opt.Quiet)
+	stats.attach(srv)
+
+	// 启动证书引导页
+	var onboard *onboarding
+	if opt.OnboardPort > 0 {
+		if onboard, err = startOnboarding(opt); err != nil {
+			return err
+		}
+		srv.RegisterOnShutdown(onboard.Close)
+	}
+
+	// 在同一端口号的 UDP 上启动 HTTP/3
+	if opt.HTTP3 {
+		h3, err := startHTTP3(srv)
+		if err != nil {
+			return err
+		}
+		srv.RegisterOnShutdown(h3.Close)
+	}
+
+	// 启动 HTTP 到 HTTPS 的重定向
+	if opt.HTTPPort > 0 {
+		redirect := redirectHandler(port)
+		if issuer != nil {
+			redirect = issuer.httpHandler(redirect)
+		}
+		redirectSrv, err := startRedirectServer(opt, redirect)
+		if err != nil {
+			return err
+		}
+		srv.RegisterOnShutdown(func() { _ = redirectSrv.Close() })
+	}
+
 	// 设置优雅关闭
 	idleConnsClosed := setupGracefulShutdown(srv)
 
 	// 输出启动信息
-	printServerInfo(opt)
+	printServerInfo(opt, policy, onboard)
+
+	// 启动服务器，HTTPS 端口上的明文 HTTP 请求会被重定向
+	ln, err := createListener(opt.Addr)
+	if err != nil {
+		return formatPortError(opt.Addr, err)
+	}
 
-	// 启动服务器
-	if err := srv.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+	// 监听建立后再申请 ACME 证书：TLS-ALPN-01 验证连接会进入监听队列，由 ServeTLS 接受并应答
+	if issuer != nil {
+		go issuer.prefetch(opt.Quiet)
+	}
+	if err := srv.ServeTLS(&sniffListener{Listener: ln, httpsPort: port}, "", ""); err != http.ErrServerClosed {
 		return err
 	}
 
 	// 等待优雅关闭完成
 	<-idleConnsClosed
+	if !opt.Quiet {
+		stats.printSummary()
+	}
 	return nil
 }
 
+// handlerOptions 根据服务器选项生成文件服务处理器的选项
+func handlerOptions(opt Options, staging *UploadStaging) HandlerOptions {
+	return HandlerOptions{
+		Root:           opt.Root,
+		Quiet:          opt.Quiet,
+		Paths:          opt.Paths,
+		TLSLog:         opt.TLSLog,
+		AltSvc:         altSvcHeader(opt),
+		Upload:         opt.Upload,
+		AllowOverwrite: opt.AllowOverwrite,
+		Staging:        staging,
+		WebDAV:         opt.WebDAV,
+	}
+}
+
 // applyMiddleware 应用中间件
 func applyMiddleware(handler http.Handler, opt Options) http.Handler {
 	// 设置默认值
@@ -75,7 +225,20 @@ func applyMiddleware(handler http.Handler, opt Options) http.Handler {
 	}
 
 	// 应用中间件：限制请求体大小，然后是基本身份验证，最后是 Gzip 压缩
-	handler = LimitRequestBodySize(maxBodyBytes)(handler)
+	// 可续传上传的数据块不受该限制，总大小由 Upload-Length 与 -upload-max-size 限制
+	limited := LimitRequestBodySize(maxBodyBytes)(handler)
+	if opt.Upload && opt.UploadStaging != "" {
+		unlimited := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isResumableChunkRequest(r) {
+				unlimited.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	} else {
+		handler = limited
+	}
 
 	// 如果配置了身份验证，则应用身份验证中间件
 	if opt.AuthUser != "" || opt.AuthPass != "" {
@@ -89,8 +252,6 @@ func applyMiddleware(handler http.Handler, opt Options) http.Handler {
 	return GzipMiddleware(handler)
 }
 
-
-
 // createHTTPServer 创建 HTTP 服务器实例
 func createHTTPServer(opt Options, handler http.Handler, tlsConfig *tls.Config) *http.Server {
 	// 设置默认值
@@ -111,7 +272,7 @@ func createHTTPServer(opt Options, handler http.Handler, tlsConfig *tls.Config)
 		maxHeaderBytes = 1 << 20 // 1 MB
 	}
 
-	return &http.Server{
+	srv := &http.Server{
 		Addr:           opt.Addr,
 		Handler:        handler,
 		TLSConfig:      tlsConfig,
@@ -120,6 +281,8 @@ func createHTTPServer(opt Options, handler http.Handler, tlsConfig *tls.Config)
 		IdleTimeout:    idleTimeout,
 		MaxHeaderBytes: maxHeaderBytes,
 	}
+	configureHTTP2(srv, opt)
+	return srv
 }
 
 // setupGracefulShutdown 设置优雅关闭
@@ -151,7 +314,7 @@ func setupGracefulShutdown(srv *http.Server) chan struct{} {
 }
 
 // printServerInfo 输出服务器信息
-func printServerInfo(opt Options) {
+func printServerInfo(opt Options, policy tlspolicy.Policy, onboard *onboarding) {
 	if opt.Quiet {
 		return
 	}
@@ -184,8 +347,12 @@ func printServerInfo(opt Options) {
 	if len(opt.Paths) > 0 {
 		fmt.Printf("🎯 分享路径: %v\n", opt.Paths)
 	}
-	fmt.Printf("🌐 访问地址: https://localhost%s\n", opt.Addr)
 	fmt.Printf("🔐 监听地址: %s\n", opt.Addr)
+	fmt.Printf("🔒 TLS 策略: %s\n", policy.Describe())
+	fmt.Printf("🔀 HTTP/2: %s\n", describeHTTP2(opt))
+	if opt.HTTP3 {
+		fmt.Printf("⚡ HTTP/3: 已启用 (UDP %s，仅 TLS 1.3)\n", opt.Addr)
+	}
 
 	// 打印超时信息
 	fmt.Printf("⏱️  超时设置: 读取=%v, 写入=%v, 空闲=%v\n", readTimeout, writeTimeout, idleTimeout)
@@ -198,9 +365,80 @@ func printServerInfo(opt Options) {
 		fmt.Printf("🔐 身份验证: 已启用 (用户: %s)\n", opt.AuthUser)
 	}
 
+	// 打印上传信息
+	if opt.Upload {
+		mode := "不覆盖已有文件"
+		if opt.AllowOverwrite {
+			mode = "允许覆盖已有文件"
+		}
+		fmt.Printf("⬆️  上传: 已启用（%s，单个请求最大 %v 字节）\n", mode, maxBodyBytes)
+		if opt.UploadStaging != "" {
+			limit := "不限制大小"
+			if opt.UploadMaxSize > 0 {
+				limit = fmt.Sprintf("最大 %v 字节", opt.UploadMaxSize)
+			}
+			fmt.Printf("⏯️  可续传上传: tus %s（%s，暂存于 %s，%v 未继续的上传会被清理）\n",
+				tusVersion, limit, opt.UploadStaging, resumableExpiry)
+		}
+		if opt.AuthUser == "" && opt.ClientCAPath == "" {
+			fmt.Println("⚠️  未启用身份验证，能访问该端口的任何人都可以上传文件")
+		}
+	}
+
+	// 打印 WebDAV 信息
+	if opt.WebDAV {
+		mode := "只读，加 -upload 允许修改"
+		switch {
+		case opt.Upload && opt.AllowOverwrite:
+			mode = "可读写，允许删除与覆盖"
+		case opt.Upload:
+			mode = "可读写，加 -upload-overwrite 允许删除与覆盖"
+		}
+		fmt.Printf("📂 WebDAV: 已启用（%s），在文件管理器中添加 WebDAV 位置即可挂载\n", mode)
+	}
+
+	// 打印客户端证书验证信息
+	if opt.ClientCAPath != "" {
+		mode := opt.ClientAuth
+		if mode == "" {
+			mode = "require"
+		}
+		fmt.Printf("🪪 客户端证书: %s (CA: %s)\n", mode, opt.ClientCAPath)
+	}
+
+	// 打印证书热加载与自动续期信息
+	if opt.CertWatch > 0 {
+		fmt.Printf("🔄 证书热加载: 每 %v 检查一次", opt.CertWatch)
+		if opt.AutoRenewDays > 0 {
+			fmt.Printf("，剩余不足 %d 天时自动续期", opt.AutoRenewDays)
+		}
+		fmt.Println()
+	} else if opt.AutoRenewDays > 0 {
+		fmt.Printf("♻️  自动续期: 剩余不足 %d 天时续期\n", opt.AutoRenewDays)
+	}
+
+	// 打印证书引导页信息
+	if onboard != nil {
+		printOnboarding(onboard, opt.QRCode)
+	}
+
+	// 打印虚拟主机信息
+	printVirtualHosts(opt)
+
+	// 打印 ACME 信息
+	if opt.ACME != nil {
+		printACME(opt.ACME, opt.Addr)
+	}
+
+	// 打印 HTTP 重定向信息
+	if opt.HTTPPort > 0 {
+		fmt.Printf("↪️  HTTP 重定向: 端口 %d → HTTPS\n", opt.HTTPPort)
+	}
+
+	// 打印访问地址（放在最后，便于扫码）
+	printAccessURLs(opt)
+
 	// 打印底部信息
 	fmt.Println("💡 提示: 在浏览器中打开访问地址即可浏览文件")
 	fmt.Print("🛑 按 Ctrl+C 停止\n\n")
 }
-
-
@@ -2,27 +2,31 @@ package server
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 
 	tlspolicy "github.com/Alhkxsj/hserve/internal/tls"
 )
 
 // LoadTLSConfig 加载并返回 TLS 配置
-func LoadTLSConfig(certPath, keyPath string) (*tls.Config, error) {
+//
+// 证书由返回的 CertReloader 提供，调用 Start 后证书文件更新无需重启即可生效。
+func LoadTLSConfig(certPath, keyPath string, quiet bool, policy tlspolicy.Policy) (*tls.Config, *CertReloader, error) {
 	// 加载证书
-	cert, err := loadCertificate(certPath, keyPath)
+	reloader, err := NewCertReloader(certPath, keyPath, quiet)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	
+
 	// 创建TLS配置
-	tlsConfig := createTLSConfig(cert)
-	return tlsConfig, nil
+	tlsConfig := createTLSConfig(reloader, policy)
+	return tlsConfig, reloader, nil
 }
 
 // loadCertificate 加载TLS证书
 func loadCertificate(certPath, keyPath string) (tls.Certificate, error) {
-	// 使用系统函数加载证书和密钥
+	// 使用系统函数加载证书和密钥，支持 PKCS#8（RSA / ECDSA / Ed25519）、PKCS#1 和 SEC1 格式
 	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
 	if err != nil {
 		// 包装错误以便更好地调试
@@ -32,7 +36,59 @@ func loadCertificate(certPath, keyPath string) (tls.Certificate, error) {
 }
 
 // createTLSConfig 创建TLS配置
-func createTLSConfig(cert tls.Certificate) *tls.Config {
-	// 使用默认的安全策略创建TLS配置
-	return tlspolicy.DefaultConfig(cert)
+func createTLSConfig(reloader *CertReloader, policy tlspolicy.Policy) *tls.Config {
+	// 使用指定的安全策略创建TLS配置
+	return tlspolicy.ReloadableConfig(reloader.GetCertificate, policy)
+}
+
+// buildTLSPolicy 根据选项生成 TLS 策略
+func buildTLSPolicy(opt Options) (tlspolicy.Policy, error) {
+	return tlspolicy.BuildPolicy(tlspolicy.PolicyOptions{
+		Profile:      opt.TLSProfile,
+		MinVersion:   opt.TLSMinVersion,
+		CipherSuites: opt.TLSCiphers,
+		Curves:       opt.TLSCurves,
+	})
+}
+
+// configureClientAuth 配置客户端证书验证（双向 TLS）
+func configureClientAuth(config *tls.Config, clientCAPath, mode string) error {
+	if clientCAPath == "" {
+		if mode != "" && mode != "none" {
+			return fmt.Errorf("启用客户端证书验证需要同时指定 -client-ca")
+		}
+		return nil
+	}
+
+	// 指定了 CA 但未指定模式时默认要求客户端证书
+	if mode == "" {
+		mode = "require"
+	}
+	clientAuth, err := tlspolicy.ParseClientAuth(mode)
+	if err != nil {
+		return err
+	}
+
+	pool, err := loadCertPool(clientCAPath)
+	if err != nil {
+		return err
+	}
+
+	config.ClientCAs = pool
+	config.ClientAuth = clientAuth
+	return nil
+}
+
+// loadCertPool 从 PEM 文件加载证书池
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取客户端 CA 证书失败: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s 中没有有效的 PEM 证书", path)
+	}
+	return pool, nil
 }
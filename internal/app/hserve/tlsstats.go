@@ -0,0 +1,151 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// handshakeErrorPrefix net/http 记录 TLS 握手失败时使用的前缀
+const handshakeErrorPrefix = "http: TLS handshake error from "
+
+// handshakeFailure 握手失败原因分类
+type handshakeFailure struct {
+	name     string   // 统计中使用的名称
+	patterns []string // 错误信息中的关键字，任一匹配即归入该类
+	hint     string   // 排查提示
+}
+
+// handshakeFailures 按匹配顺序排列的握手失败分类
+//
+// 服务器验证客户端证书失败的信息中也含有 unknown authority，因此 client-cert 需排在 unknown-ca 之前。
+var handshakeFailures = []handshakeFailure{
+	{"client-cert", []string{"client didn't provide a certificate", "failed to verify certificate"}, "客户端未提供有效的客户端证书"},
+	{"unknown-ca", []string{"unknown certificate authority", "unknown authority"}, "设备不信任签发 CA，请先安装 hserve-ca.crt"},
+	{"bad-certificate", []string{"bad certificate", "certificate unknown", "unsupported certificate", "certificate expired"}, "设备拒绝了服务器证书，请检查证书名称与有效期"},
+	{"protocol-version", []string{"protocol version", "unsupported versions", "no supported versions"}, "协议版本不匹配，旧设备可尝试 -tls-profile compat"},
+	{"no-shared-cipher", []string{"no cipher suite", "no ECDHE curve", "handshake failure", "insufficient security"}, "没有双方都支持的密码套件或曲线，可尝试 -tls-profile compat"},
+	{"plaintext-http", []string{errPlaintextRedirected.Error(), "does not look like a TLS handshake", "HTTP request to an HTTPS server"}, "客户端使用了 http:// 访问 HTTPS 端口"},
+	{"client-rejected", []string{"bad record MAC"}, "客户端中止了握手，TLS 1.3 下通常是设备不信任服务器证书"},
+	{"aborted", []string{"EOF", "connection reset", "broken pipe", "i/o timeout"}, "连接在握手过程中中断"},
+}
+
+// tlsStats 统计 TLS 握手与会话复用情况
+type tlsStats struct {
+	mu         sync.Mutex
+	quiet      bool
+	handshakes int
+	resumed    int
+	failures   map[string]int
+	seen       map[net.Conn]bool // 已统计过握手的连接
+}
+
+// newTLSStats 创建 TLS 统计器
+func newTLSStats(quiet bool) *tlsStats {
+	return &tlsStats{
+		quiet:    quiet,
+		failures: make(map[string]int),
+		seen:     make(map[net.Conn]bool),
+	}
+}
+
+// attach 将统计器挂接到服务器：握手成功通过 ConnState 统计，握手失败通过 ErrorLog 统计
+func (s *tlsStats) attach(srv *http.Server) {
+	srv.ConnState = s.trackConn
+	srv.ErrorLog = log.New(s, "", 0)
+}
+
+// trackConn 连接首次进入活动状态时记录一次成功的握手（包括会话复用）
+//
+// TLS 1.3 中客户端在服务器发送 Finished 之后才验证证书，
+// 因此以收到第一个请求为准，而不是服务器端握手完成。
+func (s *tlsStats) trackConn(conn net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch state {
+	case http.StateActive:
+		if s.seen[conn] {
+			return
+		}
+		s.seen[conn] = true
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			s.handshakes++
+			if tlsConn.ConnectionState().DidResume {
+				s.resumed++
+			}
+		}
+	case http.StateClosed, http.StateHijacked:
+		delete(s.seen, conn)
+	}
+}
+
+// Write 实现 io.Writer，接收 http.Server 的错误日志
+//
+// 握手失败会被分类计数并附带排查提示输出，其他日志原样写入标准错误。
+func (s *tlsStats) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\n")
+
+	rest, ok := strings.CutPrefix(line, handshakeErrorPrefix)
+	if !ok {
+		return os.Stderr.Write(p)
+	}
+
+	remote, reason, _ := strings.Cut(rest, ": ")
+	failure := classifyHandshakeError(reason)
+
+	s.mu.Lock()
+	s.failures[failure.name]++
+	s.mu.Unlock()
+
+	if !s.quiet {
+		fmt.Fprintf(os.Stderr, "[%s] ⚠️  TLS 握手失败 %s: %s (%s)\n",
+			time.Now().Format("15:04:05"), remote, failure.hint, reason)
+	}
+	return len(p), nil
+}
+
+// classifyHandshakeError 根据错误信息判断握手失败原因
+func classifyHandshakeError(reason string) handshakeFailure {
+	for _, f := range handshakeFailures {
+		for _, pattern := range f.patterns {
+			if strings.Contains(reason, pattern) {
+				return f
+			}
+		}
+	}
+	return handshakeFailure{name: "other", hint: "其他错误"}
+}
+
+// printSummary 输出握手统计
+func (s *tlsStats) printSummary() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Printf("📊 TLS 握手: 成功 %d 次（会话复用 %d 次）", s.handshakes, s.resumed)
+
+	total := 0
+	var names []string
+	for name, n := range s.failures {
+		total += n
+		names = append(names, name)
+	}
+	if total == 0 {
+		fmt.Println("，失败 0 次")
+		return
+	}
+
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, s.failures[name]))
+	}
+	fmt.Printf("，失败 %d 次: %s\n", total, strings.Join(parts, " "))
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestClassifyHandshakeError 使用 net/http 实际输出的握手错误日志
+func TestClassifyHandshakeError(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"http: TLS handshake error from 192.168.1.20:51234: remote error: tls: unknown certificate authority", "unknown-ca"},
+		{"http: TLS handshake error from 192.168.1.20:51234: remote error: tls: bad certificate", "bad-certificate"},
+		{"http: TLS handshake error from 192.168.1.20:51234: remote error: tls: certificate expired", "bad-certificate"},
+		{"http: TLS handshake error from 192.168.1.20:51234: tls: client offered only unsupported versions: [303 302 301]", "protocol-version"},
+		{"http: TLS handshake error from 192.168.1.20:51234: remote error: tls: protocol version not supported", "protocol-version"},
+		{"http: TLS handshake error from 192.168.1.20:51234: tls: no cipher suite supported by both client and server; client offered: [c02b 1301]", "no-shared-cipher"},
+		{"http: TLS handshake error from 192.168.1.20:51234: tls: no ECDHE curve supported by both client and server", "no-shared-cipher"},
+		{"http: TLS handshake error from 192.168.1.20:51234: tls: client didn't provide a certificate", "client-cert"},
+		{"http: TLS handshake error from 192.168.1.20:51234: tls: failed to verify certificate: x509: certificate signed by unknown authority", "client-cert"},
+		{"http: TLS handshake error from 192.168.1.20:51234: client sent an HTTP request to an HTTPS server", "plaintext-http"},
+		{"http: TLS handshake error from 192.168.1.20:51234: tls: first record does not look like a TLS handshake", "plaintext-http"},
+		{"http: TLS handshake error from 192.168.1.20:51234: " + errPlaintextRedirected.Error(), "plaintext-http"},
+		{"http: TLS handshake error from 192.168.1.20:51234: remote error: tls: bad record MAC", "client-rejected"},
+		{"http: TLS handshake error from 192.168.1.20:51234: EOF", "aborted"},
+		{"http: TLS handshake error from 192.168.1.20:51234: unexpected EOF", "aborted"},
+		{"http: TLS handshake error from [fe80::1%wlan0]:51234: read tcp [fe80::2%wlan0]:8443->[fe80::1%wlan0]:51234: read: connection reset by peer", "aborted"},
+		{"http: TLS handshake error from 192.168.1.20:51234: tls: unsupported SSLv2 handshake received", "other"},
+	}
+
+	for _, tt := range tests {
+		stats := newTLSStats(true)
+		if _, err := stats.Write([]byte(tt.line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if stats.failures[tt.want] != 1 || len(stats.failures) != 1 {
+			t.Errorf("%q: failures = %v, want %s=1", tt.line, stats.failures, tt.want)
+		}
+	}
+}
+
+// TestTLSStatsLiveHandshakes 由真实的失败握手产生 net/http 的错误日志并检查分类
+//
+// Go 升级改变错误措辞时这里会失败，而不是把失败都归入 other。
+func TestTLSStatsLiveHandshakes(t *testing.T) {
+	cert, pool := newTestTLSCertificate(t, "files.lan")
+
+	tests := []struct {
+		name   string
+		server func(*tls.Config)
+		client func(addr string) // 发起一次失败的连接
+		want   string
+	}{
+		{"unknown ca", nil, func(addr string) {
+			// 浏览器不信任 CA 时发送 unknown_ca 警报（Go 客户端发送的是 bad_certificate），这里直接发送该警报
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				_, _ = conn.Write([]byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 48})
+				_, _ = conn.Read(make([]byte, 1))
+				conn.Close()
+			}
+		}, "unknown-ca"},
+		{"untrusted by go client", nil, func(addr string) {
+			dialTLS(addr, &tls.Config{ServerName: "files.lan"})
+		}, "bad-certificate"},
+		{"bad certificate name", nil, func(addr string) {
+			dialTLS(addr, &tls.Config{ServerName: "other.lan", RootCAs: pool})
+		}, "bad-certificate"},
+		{"protocol version", func(c *tls.Config) { c.MinVersion = tls.VersionTLS13 }, func(addr string) {
+			dialTLS(addr, &tls.Config{ServerName: "files.lan", RootCAs: pool, MaxVersion: tls.VersionTLS12})
+		}, "protocol-version"},
+		{"no shared cipher", func(c *tls.Config) {
+			c.MaxVersion = tls.VersionTLS12
+			c.CipherSuites = []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}
+		}, func(addr string) {
+			dialTLS(addr, &tls.Config{ServerName: "files.lan", RootCAs: pool,
+				CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}})
+		}, "no-shared-cipher"},
+		{"client certificate", func(c *tls.Config) { c.ClientAuth = tls.RequireAndVerifyClientCert }, func(addr string) {
+			conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: "files.lan", RootCAs: pool})
+			if err == nil {
+				// TLS 1.3 中服务器在客户端 Finished 之后才检查客户端证书
+				_, _ = conn.Read(make([]byte, 1))
+				conn.Close()
+			}
+		}, "client-cert"},
+		{"plaintext http", nil, func(addr string) {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				_, _ = conn.Write([]byte("GET / HTTP/1.1\r\nHost: files.lan\r\n\r\n"))
+				_, _ = conn.Read(make([]byte, 1024))
+				conn.Close()
+			}
+		}, "plaintext-http"},
+		{"eof", nil, func(addr string) {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				_, _ = conn.Write([]byte{tlsRecordTypeHandshake})
+				conn.Close()
+			}
+		}, "aborted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+			srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, ClientCAs: pool}
+			if tt.server != nil {
+				tt.server(srv.TLS)
+			}
+			stats := newTLSStats(true)
+			stats.attach(srv.Config)
+			srv.StartTLS()
+			defer srv.Close()
+
+			tt.client(srv.Listener.Addr().String())
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				stats.mu.Lock()
+				failures := len(stats.failures)
+				got := stats.failures[tt.want]
+				stats.mu.Unlock()
+				if got == 1 {
+					return
+				}
+				if failures > 0 || time.Now().After(deadline) {
+					t.Fatalf("failures = %v, want %s=1", stats.failures, tt.want)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
+
+// dialTLS 建立 TLS 连接，握手失败时忽略错误
+func dialTLS(addr string, config *tls.Config) {
+	if conn, err := tls.Dial("tcp", addr, config); err == nil {
+		conn.Close()
+	}
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// uploadIdleTimeout 上传过程中允许的最长停顿
+//
+// 服务器的 ReadTimeout / WriteTimeout 从请求开始计时，大文件在手机 Wi-Fi 上传不完，
+// 因此上传期间改为每收到数据就顺延截止时间，只在连接停顿时中止。
+const uploadIdleTimeout = 30 * time.Second
+
+// errFileExists 目标文件已存在且不允许覆盖
+var errFileExists = errors.New("file already exists")
+
+// errInvalidUploadName 上传的文件名不允许使用
+var errInvalidUploadName = errors.New("invalid file name")
+
+// handleUpload 处理上传请求，返回 false 表示不是上传请求
+//
+// PUT 将请求体保存为请求路径对应的文件；POST 到目录地址时保存 multipart 表单中的所有文件。
+func handleUpload(w http.ResponseWriter, r *http.Request, opt HandlerOptions) bool {
+	switch {
+	case r.Method == http.MethodPut:
+		handlePutUpload(w, r, opt)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/"):
+		if isCrossSiteRequest(r) {
+			http.Error(w, "Cross-site upload rejected", http.StatusForbidden)
+			return true
+		}
+		handleFormUpload(w, r, opt)
+	default:
+		return false
+	}
+	return true
+}
+
+// isCrossSiteRequest 检查请求是否由其他站点的页面发起
+//
+// 跨站的 multipart 表单提交不需要预检，浏览器还会附带已缓存的 Basic 认证凭据，
+// 任何网页都可以借此向共享目录写入文件。PUT 与 tus 请求会触发 CORS 预检，不受影响。
+// 优先使用 Sec-Fetch-Site，旧浏览器退回到比较 Origin 与 Host；两者都没有的请求不是来自浏览器表单。
+func isCrossSiteRequest(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site != "same-origin" && site != "none"
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	// 沙箱页面等场景下 Origin 为 null，解析后没有主机名
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return true
+	}
+	return !strings.EqualFold(u.Host, r.Host)
+}
+
+// handlePutUpload 将请求体保存为文件，新建时返回 201，覆盖时返回 204
+func handlePutUpload(w http.ResponseWriter, r *http.Request, opt HandlerOptions) {
+	urlPath := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") || urlPath == "/" {
+		http.Error(w, "PUT requires a file path", http.StatusBadRequest)
+		return
+	}
+
+	dir, ok := uploadDir(w, path.Dir(urlPath), opt)
+	if !ok {
+		return
+	}
+
+	target := filepath.Join(dir, path.Base(urlPath))
+	_, statErr := os.Stat(target)
+	existed := statErr == nil
+
+	if err := saveUpload(dir, path.Base(urlPath), uploadBody(w, r.Body), opt.AllowOverwrite); err != nil {
+		sendUploadError(w, err)
+		return
+	}
+
+	if existed {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Location", r.URL.EscapedPath())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = fmt.Fprintln(w, "Created")
+}
+
+// handleFormUpload 保存 multipart 表单中的文件，完成后重定向回目录列表
+func handleFormUpload(w http.ResponseWriter, r *http.Request, opt HandlerOptions) {
+	urlPath := path.Clean("/" + r.URL.Path)
+	dir, ok := uploadDir(w, urlPath, opt)
+	if !ok {
+		return
+	}
+
+	r.Body = uploadBody(w, r.Body)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data", http.StatusBadRequest)
+		return
+	}
+
+	saved := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			sendUploadError(w, err)
+			return
+		}
+
+		if err := saveFormPart(dir, urlPath, part, opt); err != nil {
+			sendUploadError(w, err)
+			return
+		}
+		if part.FileName() != "" {
+			saved++
+		}
+	}
+
+	if saved == 0 {
+		http.Error(w, "No file in form", http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, r.URL.EscapedPath(), http.StatusSeeOther)
+}
+
+// saveFormPart 保存表单中的一个文件，非文件字段会被忽略
+func saveFormPart(dir, urlPath string, part *multipart.Part, opt HandlerOptions) error {
+	defer part.Close()
+
+	// FileName 已去掉客户端提供的目录部分
+	name := part.FileName()
+	if name == "" {
+		return nil
+	}
+	if !isValidUploadName(name) ||
+		!isRequestAllowed(path.Join(urlPath, name), opt.Root, opt.Paths, len(opt.Paths) > 0) {
+		return fmt.Errorf("%w: %s", errInvalidUploadName, name)
+	}
+
+	return saveUpload(dir, name, part, opt.AllowOverwrite)
+}
+
+// isValidUploadName 检查上传文件名，不允许路径分隔符与隐藏文件
+func isValidUploadName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`)
+}
+
+// uploadDir 检查上传目标目录并返回其本地路径
+//
+// 目录必须已存在，且解析符号链接后仍位于共享目录内。
+func uploadDir(w http.ResponseWriter, urlDir string, opt HandlerOptions) (string, bool) {
+	if !isPathSafe(urlDir, opt.Root) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return "", false
+	}
+
+	dir := filepath.Join(opt.Root, filepath.FromSlash(urlDir))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		http.Error(w, "Parent directory does not exist", http.StatusConflict)
+		return "", false
+	}
+	return dir, true
+}
+
+// saveUpload 先写入同目录下的临时文件，完成后再移动到目标位置
+//
+// 上传中断时不会留下不完整的文件，其他客户端也不会读到写了一半的内容。
+func saveUpload(dir, name string, body io.Reader, overwrite bool) error {
+	target := filepath.Join(dir, name)
+	if info, err := os.Stat(target); err == nil {
+		if info.IsDir() || !overwrite {
+			return errFileExists
+		}
+	}
+
+	// 以 . 开头，上传过程中不会出现在目录列表里
+	tmp, err := os.CreateTemp(dir, ".hserve-upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return commitUpload(tmp.Name(), target, overwrite)
+}
+
+// commitUpload 将临时文件移动到目标位置
+//
+// 不允许覆盖时优先使用硬链接，目标已存在时链接会失败，避免两个同名上传互相覆盖。
+func commitUpload(tmp, target string, overwrite bool) error {
+	if overwrite {
+		return os.Rename(tmp, target)
+	}
+
+	err := os.Link(tmp, target)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return errFileExists
+	}
+
+	// 文件系统不支持硬链接（例如 Android 的 /sdcard），退回到检查后重命名
+	if _, err := os.Lstat(target); err == nil {
+		return errFileExists
+	}
+	return os.Rename(tmp, target)
+}
+
+// uploadBody 包装请求体，每次读到数据时顺延读写截止时间
+func uploadBody(w http.ResponseWriter, body io.ReadCloser) io.ReadCloser {
+	return &idleTimeoutBody{ReadCloser: body, rc: http.NewResponseController(w)}
+}
+
+// idleTimeoutBody 在读取过程中不断顺延截止时间的请求体
+type idleTimeoutBody struct {
+	io.ReadCloser
+	rc *http.ResponseController
+}
+
+// Read 实现 io.Reader
+//
+// 写入截止时间一并顺延，保证上传完成后仍能发送响应。
+// 不支持设置截止时间的连接沿用服务器超时。
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	deadline := time.Now().Add(uploadIdleTimeout)
+	_ = b.rc.SetReadDeadline(deadline)
+	_ = b.rc.SetWriteDeadline(deadline)
+	return b.ReadCloser.Read(p)
+}
+
+// sendUploadError 将上传错误转换为 HTTP 响应
+func sendUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errFileExists):
+		http.Error(w, "File already exists", http.StatusConflict)
+	case errors.Is(err, errInvalidUploadName):
+		http.Error(w, "Invalid file name", http.StatusBadRequest)
+	case errors.As(err, &maxBytesErr):
+		sendTooLargeResponse(w)
+	default:
+		http.Error(w, "Upload failed", http.StatusInternalServerError)
+	}
+}
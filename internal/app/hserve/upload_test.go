@@ -0,0 +1,186 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFormUploadRequest 创建上传单个文件的 multipart 表单请求
+func newFormUploadRequest(t *testing.T, target, name, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write([]byte(content))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestFormUploadCrossSite(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"no origin", nil, http.StatusSeeOther},
+		{"same origin", map[string]string{"Origin": "https://example.com"}, http.StatusSeeOther},
+		{"foreign origin", map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		{"null origin", map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"other port", map[string]string{"Origin": "https://example.com:8443"}, http.StatusForbidden},
+		{"fetch same-origin", map[string]string{"Sec-Fetch-Site": "same-origin"}, http.StatusSeeOther},
+		{"fetch cross-site", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://example.com"}, http.StatusForbidden},
+		{"fetch same-site", map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRoot(t)
+			handler := NewHandler(HandlerOptions{Root: root, Quiet: true, Upload: true})
+
+			req := newFormUploadRequest(t, "https://example.com/", "a.txt", "hello")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			_, err := os.Stat(filepath.Join(root, "a.txt"))
+			if saved := err == nil; saved != (tt.want == http.StatusSeeOther) {
+				t.Fatalf("file saved = %v, want %v", saved, !saved)
+			}
+		})
+	}
+}
+
+func TestPutUpload(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		body      io.Reader
+		overwrite bool
+		want      int
+		content   string // 目标文件上传后的内容
+	}{
+		{"new file", "/new.txt", strings.NewReader("data"), false, http.StatusCreated, "data"},
+		{"existing file", "/exists.txt", strings.NewReader("data"), false, http.StatusConflict, "old"},
+		{"overwrite", "/exists.txt", strings.NewReader("data"), true, http.StatusNoContent, "data"},
+		{"directory", "/dir", strings.NewReader("data"), true, http.StatusConflict, ""},
+		{"trailing slash", "/dir/", strings.NewReader("data"), false, http.StatusBadRequest, ""},
+		{"hidden file", "/.env", strings.NewReader("data"), false, http.StatusForbidden, ""},
+		{"missing dir", "/nodir/a.txt", strings.NewReader("data"), false, http.StatusConflict, ""},
+		{"interrupted", "/new.txt", io.MultiReader(strings.NewReader("da"), errReader{}), false, http.StatusInternalServerError, ""},
+		{"interrupted overwrite", "/exists.txt", io.MultiReader(strings.NewReader("da"), errReader{}), true, http.StatusInternalServerError, "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRoot(t)
+			writeTestFile(t, root, "exists.txt", "old")
+			mustMkdir(t, filepath.Join(root, "dir"))
+			handler := NewHandler(HandlerOptions{Root: root, Quiet: true, Upload: true, AllowOverwrite: tt.overwrite})
+
+			req := httptest.NewRequest(http.MethodPut, tt.target, tt.body)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusCreated && rec.Header().Get("Location") != tt.target {
+				t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), tt.target)
+			}
+
+			got, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(tt.target)))
+			if tt.content == "" {
+				if err == nil && !strings.HasSuffix(tt.target, "/") && tt.target != "/dir" {
+					t.Fatalf("%s was created", tt.target)
+				}
+			} else if string(got) != tt.content {
+				t.Fatalf("content = %q, want %q", got, tt.content)
+			}
+			assertNoTempFiles(t, root)
+		})
+	}
+}
+
+func TestFormUpload(t *testing.T) {
+	tests := []struct {
+		name      string
+		filename  string
+		overwrite bool
+		want      int
+		file      string // 检查的目标文件
+		content   string // 为空表示目标文件不应存在
+	}{
+		{"new file", "a.txt", false, http.StatusSeeOther, "a.txt", "hello"},
+		{"existing file", "exists.txt", false, http.StatusConflict, "exists.txt", "old"},
+		{"overwrite", "exists.txt", true, http.StatusSeeOther, "exists.txt", "hello"},
+		{"hidden file", ".env", false, http.StatusBadRequest, ".env", ""},
+		{"client path stripped", "../../etc/a.txt", false, http.StatusSeeOther, "a.txt", "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRoot(t)
+			writeTestFile(t, root, "exists.txt", "old")
+			handler := NewHandler(HandlerOptions{Root: root, Quiet: true, Upload: true, AllowOverwrite: tt.overwrite})
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, newFormUploadRequest(t, "/", tt.filename, "hello"))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+
+			got, err := os.ReadFile(filepath.Join(root, tt.file))
+			if tt.content == "" {
+				if err == nil {
+					t.Fatalf("%s was created", tt.file)
+				}
+			} else if string(got) != tt.content {
+				t.Fatalf("content = %q, want %q", got, tt.content)
+			}
+			assertNoTempFiles(t, root)
+		})
+	}
+}
+
+func TestCommitUploadNoOverwrite(t *testing.T) {
+	dir := newTestRoot(t)
+	target := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(dir, ".hserve-upload-1")
+	if err := os.WriteFile(tmp, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := commitUpload(tmp, target, false); !errors.Is(err, errFileExists) {
+		t.Fatalf("err = %v, want errFileExists", err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "old" {
+		t.Fatalf("target overwritten: %q", got)
+	}
+	if err := commitUpload(tmp, target, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Fatalf("content = %q, want new", got)
+	}
+}
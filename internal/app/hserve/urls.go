@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Alhkxsj/hserve/internal/qrcode"
+	"github.com/Alhkxsj/hserve/pkg/certgen"
+)
+
+// accessHosts 返回可用于访问服务器的主机列表，首个为首选地址
+//
+// 监听所有地址时列出本机局域网地址（IPv4 优先），最后附加 localhost；
+// 监听指定地址时只返回该地址。
+func accessHosts(listenHost string) []string {
+	ip := net.ParseIP(listenHost)
+	if listenHost != "" && (ip == nil || !ip.IsUnspecified()) {
+		return []string{listenHost}
+	}
+
+	// 显式监听 0.0.0.0 时只接受 IPv4 连接
+	ipv4Only := ip != nil && ip.To4() != nil
+
+	var v4, v6 []string
+	localIPs, _ := certgen.LocalIPs()
+	for _, lip := range localIPs {
+		if lip.To4() != nil {
+			v4 = append(v4, lip.String())
+		} else if !ipv4Only {
+			v6 = append(v6, lip.String())
+		}
+	}
+
+	return append(append(v4, v6...), "localhost")
+}
+
+// hostURL 拼接访问地址，IPv6 地址会加上方括号
+func hostURL(scheme, host, port string) string {
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// isLoopbackURLHost 检查主机是否只能在本机访问
+func isLoopbackURLHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// printQRCode 在终端中输出 URL 的二维码
+func printQRCode(title, url string) {
+	code, err := qrcode.Encode(url)
+	if err != nil {
+		return
+	}
+	fmt.Println(title)
+	fmt.Print(code.Terminal())
+}
+
+// printAccessURLs 输出全部访问地址，并为首选的局域网地址显示二维码
+func printAccessURLs(opt Options) {
+	host, port, err := net.SplitHostPort(opt.Addr)
+	if err != nil {
+		fmt.Printf("🌐 访问地址: https://%s\n", opt.Addr)
+		return
+	}
+
+	hosts := accessHosts(host)
+	fmt.Println("🌐 访问地址:")
+	for _, h := range hosts {
+		fmt.Printf("   %s\n", hostURL("https", h, port))
+	}
+
+	// 只能在本机访问的地址没有必要扫码
+	if opt.QRCode && !isLoopbackURLHost(hosts[0]) {
+		printQRCode("📷 用手机扫描二维码访问:", hostURL("https", hosts[0], port))
+	}
+}
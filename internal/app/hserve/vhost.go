@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// VirtualHost 虚拟主机配置
+//
+// 按 TLS 握手中的 SNI 选择证书，按请求的 Host 选择共享目录。
+// 未指定证书时使用默认服务器证书，未指定身份验证时沿用全局设置。
+type VirtualHost struct {
+	Host     string `json:"host"`                // 主机名，支持最左侧的通配符，例如 *.lan
+	Root     string `json:"root"`                // 共享目录
+	CertPath string `json:"cert,omitempty"`      // 证书文件（PEM）
+	KeyPath  string `json:"key,omitempty"`       // 私钥文件（PEM）
+	AuthUser string `json:"auth_user,omitempty"` // 基本身份验证用户名
+	AuthPass string `json:"auth_pass,omitempty"` // 基本身份验证密码
+}
+
+// LoadVirtualHosts 读取虚拟主机配置文件（JSON 数组）
+//
+// 相对路径以配置文件所在目录为基准。
+func LoadVirtualHosts(path string) ([]VirtualHost, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取虚拟主机配置失败: %w", err)
+	}
+
+	var hosts []VirtualHost
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&hosts); err != nil {
+		return nil, fmt.Errorf("解析虚拟主机配置 %s 失败: %w", path, err)
+	}
+
+	baseDir := filepath.Dir(path)
+	seen := make(map[string]bool)
+	for i := range hosts {
+		vh := &hosts[i]
+		if err := normalizeVirtualHost(vh, baseDir); err != nil {
+			return nil, fmt.Errorf("虚拟主机配置第 %d 项: %w", i+1, err)
+		}
+		if seen[vh.Host] {
+			return nil, fmt.Errorf("虚拟主机 %s 重复配置", vh.Host)
+		}
+		seen[vh.Host] = true
+	}
+
+	return hosts, nil
+}
+
+// normalizeVirtualHost 校验并规范化单个虚拟主机配置
+func normalizeVirtualHost(vh *VirtualHost, baseDir string) error {
+	vh.Host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(vh.Host)), ".")
+	if vh.Host == "" {
+		return fmt.Errorf("缺少 host")
+	}
+	if strings.ContainsAny(vh.Host, ":/ ") || strings.Contains(vh.Host[1:], "*") ||
+		(strings.HasPrefix(vh.Host, "*") && !strings.HasPrefix(vh.Host, "*.")) {
+		return fmt.Errorf("无效的主机名: %s", vh.Host)
+	}
+
+	if vh.Root == "" {
+		return fmt.Errorf("%s 缺少 root", vh.Host)
+	}
+	vh.Root = resolvePath(baseDir, vh.Root)
+	if info, err := os.Stat(vh.Root); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s 的共享目录 %s 不存在", vh.Host, vh.Root)
+	}
+
+	if (vh.CertPath == "") != (vh.KeyPath == "") {
+		return fmt.Errorf("%s 的 cert 与 key 必须同时指定", vh.Host)
+	}
+	if vh.CertPath != "" {
+		vh.CertPath = resolvePath(baseDir, vh.CertPath)
+		vh.KeyPath = resolvePath(baseDir, vh.KeyPath)
+	}
+
+	return nil
+}
+
+// resolvePath 将相对路径转换为基于 baseDir 的绝对路径
+func resolvePath(baseDir, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// site 运行中的虚拟主机
+type site struct {
+	VirtualHost
+	reloader *CertReloader // 为空时使用默认证书
+	handler  http.Handler
+}
+
+// virtualHosts 按主机名分发证书与请求
+type virtualHosts struct {
+	sites []*site
+}
+
+// newVirtualHosts 加载各虚拟主机的证书并创建请求处理器
+func newVirtualHosts(opt Options, staging *UploadStaging) (*virtualHosts, error) {
+	v := &virtualHosts{}
+	for _, vh := range opt.VirtualHosts {
+		s := &site{VirtualHost: vh}
+
+		if vh.CertPath != "" {
+			if err := checkCertificateFiles(vh.CertPath, vh.KeyPath); err != nil {
+				return nil, err
+			}
+			if err := checkCertificateHealth(vh.CertPath, vh.KeyPath, "", opt.Quiet); err != nil {
+				return nil, fmt.Errorf("虚拟主机 %s: %w", vh.Host, err)
+			}
+			reloader, err := NewCertReloader(vh.CertPath, vh.KeyPath, opt.Quiet)
+			if err != nil {
+				return nil, fmt.Errorf("虚拟主机 %s: %w", vh.Host, err)
+			}
+			s.reloader = reloader
+		}
+
+		siteOpt := opt
+		siteOpt.Root = vh.Root
+		siteOpt.Paths = nil
+		if vh.AuthUser != "" || vh.AuthPass != "" {
+			siteOpt.AuthUser, siteOpt.AuthPass = vh.AuthUser, vh.AuthPass
+		}
+		s.handler = applyMiddleware(NewHandler(handlerOptions(siteOpt, staging)), siteOpt)
+
+		v.sites = append(v.sites, s)
+	}
+	return v, nil
+}
+
+// match 返回与主机名匹配的虚拟主机，精确匹配优先于通配符
+func (v *virtualHosts) match(host string) *site {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return nil
+	}
+
+	for _, s := range v.sites {
+		if s.Host == host {
+			return s
+		}
+	}
+
+	// 通配符只匹配一级子域名
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		wildcard := "*" + host[i:]
+		for _, s := range v.sites {
+			if s.Host == wildcard {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// getCertificate 按 SNI 选择证书，未匹配或未配置证书时使用 fallback
+func (v *virtualHosts) getCertificate(fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if s := v.match(hello.ServerName); s != nil && s.reloader != nil {
+			return s.reloader.GetCertificate(hello)
+		}
+		return fallback(hello)
+	}
+}
+
+// route 按 Host 分发请求，未匹配时交给 fallback
+//
+// SNI 与 Host 指向不同虚拟主机时返回 421，客户端会为该主机重新建立连接，
+// 避免使用一个站点的证书访问另一个站点的内容。
+func (v *virtualHosts) route(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := v.match(requestHost(r))
+		if r.TLS != nil && r.TLS.ServerName != "" && v.match(r.TLS.ServerName) != s {
+			http.Error(w, "Misdirected Request", http.StatusMisdirectedRequest)
+			return
+		}
+
+		if s == nil {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		s.handler.ServeHTTP(w, r)
+	})
+}
+
+// start 开始检查各虚拟主机的证书文件
+func (v *virtualHosts) start(interval time.Duration, renewWithin int) {
+	for _, s := range v.sites {
+		if s.reloader != nil {
+			s.reloader.Start(interval, renewWithin)
+		}
+	}
+}
+
+// stop 停止检查证书文件
+func (v *virtualHosts) stop() {
+	for _, s := range v.sites {
+		if s.reloader != nil {
+			s.reloader.Stop()
+		}
+	}
+}
+
+// requestHost 返回请求 Host 中的主机名部分
+func requestHost(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.Host); err == nil {
+		return host
+	}
+	return r.Host
+}
+
+// printVirtualHosts 输出虚拟主机信息
+func printVirtualHosts(opt Options) {
+	if len(opt.VirtualHosts) == 0 {
+		return
+	}
+
+	_, port, _ := net.SplitHostPort(opt.Addr)
+	fmt.Println("🏠 虚拟主机:")
+	for _, vh := range opt.VirtualHosts {
+		cert := "默认证书"
+		if vh.CertPath != "" {
+			cert = vh.CertPath
+		}
+		url := vh.Host
+		if !strings.HasPrefix(vh.Host, "*") {
+			url = hostURL("https", vh.Host, port)
+		}
+		fmt.Printf("   %s → %s（%s）\n", url, vh.Root, cert)
+	}
+}
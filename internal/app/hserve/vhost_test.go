@@ -0,0 +1,218 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestCertificate 将自签名证书与私钥写入目录，返回文件路径
+func writeTestCertificate(t *testing.T, dir string, names ...string) (string, string) {
+	t.Helper()
+	cert, _ := newTestTLSCertificate(t, names...)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath := filepath.Join(dir, names[0]+".pem")
+	keyPath := filepath.Join(dir, names[0]+"-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+// newTestVirtualHosts 创建只包含主机名与响应处理器的虚拟主机，处理器返回主机名
+func newTestVirtualHosts(hosts ...string) *virtualHosts {
+	v := &virtualHosts{}
+	for _, host := range hosts {
+		name := host
+		v.sites = append(v.sites, &site{
+			VirtualHost: VirtualHost{Host: host},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, name)
+			}),
+		})
+	}
+	return v
+}
+
+func TestLoadVirtualHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string   // 为空表示应加载成功
+		hosts   []string // 规范化后的主机名
+	}{
+		{"valid", `[{"host": "Files.LAN.", "root": "site"}, {"host": "*.lan", "root": "site"}]`, "", []string{"files.lan", "*.lan"}},
+		{"cert and key", `[{"host": "files.lan", "root": "site", "cert": "c.pem", "key": "k.pem"}]`, "", []string{"files.lan"}},
+		{"unknown field", `[{"host": "files.lan", "root": "site", "tls": true}]`, "unknown field", nil},
+		{"duplicate", `[{"host": "files.lan", "root": "site"}, {"host": "FILES.lan.", "root": "site"}]`, "重复", nil},
+		{"duplicate wildcard", `[{"host": "*.lan", "root": "site"}, {"host": "*.LAN", "root": "site"}]`, "重复", nil},
+		{"missing host", `[{"root": "site"}]`, "缺少 host", nil},
+		{"host with port", `[{"host": "files.lan:8443", "root": "site"}]`, "无效的主机名", nil},
+		{"inner wildcard", `[{"host": "files.*.lan", "root": "site"}]`, "无效的主机名", nil},
+		{"partial wildcard", `[{"host": "*files.lan", "root": "site"}]`, "无效的主机名", nil},
+		{"missing root", `[{"host": "files.lan"}]`, "缺少 root", nil},
+		{"root not found", `[{"host": "files.lan", "root": "missing"}]`, "不存在", nil},
+		{"cert without key", `[{"host": "files.lan", "root": "site", "cert": "c.pem"}]`, "同时指定", nil},
+		{"not an array", `{"host": "files.lan", "root": "site"}`, "解析虚拟主机配置", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRoot(t)
+			mustMkdir(t, filepath.Join(dir, "site"))
+			path := filepath.Join(dir, "vhosts.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			hosts, err := LoadVirtualHosts(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hosts) != len(tt.hosts) {
+				t.Fatalf("hosts = %+v", hosts)
+			}
+			for i, vh := range hosts {
+				if vh.Host != tt.hosts[i] {
+					t.Errorf("host %d = %q, want %q", i, vh.Host, tt.hosts[i])
+				}
+				// 相对路径以配置文件所在目录为基准
+				if vh.Root != filepath.Join(dir, "site") {
+					t.Errorf("root = %q, want %q", vh.Root, filepath.Join(dir, "site"))
+				}
+				if vh.CertPath != "" && vh.CertPath != filepath.Join(dir, "c.pem") {
+					t.Errorf("cert = %q", vh.CertPath)
+				}
+			}
+		})
+	}
+}
+
+func TestVirtualHostsMatch(t *testing.T) {
+	v := newTestVirtualHosts("files.lan", "*.lan", "*.home.lan")
+
+	tests := []struct {
+		host string
+		want string // 为空表示不匹配
+	}{
+		{"files.lan", "files.lan"},
+		{"FILES.LAN.", "files.lan"},
+		{"photos.lan", "*.lan"},
+		{"nas.home.lan", "*.home.lan"},
+		{"a.b.lan", ""}, // 通配符只匹配一级子域名
+		{"lan", ""},
+		{"files.lan.evil", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if s := v.match(tt.host); s != nil {
+			got = s.Host
+		}
+		if got != tt.want {
+			t.Errorf("match(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestVirtualHostsRoute(t *testing.T) {
+	v := newTestVirtualHosts("files.lan", "*.lan")
+	handler := v.route(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "default")
+	}))
+
+	tests := []struct {
+		name string
+		host string
+		sni  string // 为空表示明文或未发送 SNI
+		want int
+		body string
+	}{
+		{"exact", "files.lan", "files.lan", http.StatusOK, "files.lan"},
+		{"exact with port", "files.lan:8443", "files.lan", http.StatusOK, "files.lan"},
+		{"wildcard", "photos.lan", "photos.lan", http.StatusOK, "*.lan"},
+		{"same site via wildcard", "music.lan", "photos.lan", http.StatusOK, "*.lan"},
+		{"no sni", "files.lan", "", http.StatusOK, "files.lan"},
+		{"unmatched", "192.168.1.20", "", http.StatusOK, "default"},
+		{"unmatched with ip sni", "192.168.1.20:8443", "", http.StatusOK, "default"},
+		{"sni and host differ", "files.lan", "photos.lan", http.StatusMisdirectedRequest, ""},
+		{"sni site, host default", "192.168.1.20", "files.lan", http.StatusMisdirectedRequest, ""},
+		{"sni default, host site", "files.lan", "other.example", http.StatusMisdirectedRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			req.TLS = &tls.ConnectionState{ServerName: tt.sni}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Fatalf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestVirtualHostsGetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	v := newTestVirtualHosts("files.lan", "*.lan", "nocert.example")
+	for _, s := range v.sites[:2] {
+		certPath, keyPath := writeTestCertificate(t, dir, strings.TrimPrefix(s.Host, "*."))
+		reloader, err := NewCertReloader(certPath, keyPath, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.reloader = reloader
+	}
+
+	fallback := &tls.Certificate{}
+	getCertificate := v.getCertificate(func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return fallback, nil
+	})
+
+	tests := []struct {
+		sni  string
+		want *tls.Certificate
+	}{
+		{"files.lan", v.sites[0].reloader.cert},
+		{"Files.Lan", v.sites[0].reloader.cert},
+		{"photos.lan", v.sites[1].reloader.cert},
+		{"nocert.example", fallback}, // 虚拟主机未配置证书时使用默认证书
+		{"other.example", fallback},
+		{"", fallback},
+	}
+	for _, tt := range tests {
+		got, err := getCertificate(&tls.ClientHelloInfo{ServerName: tt.sni})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("SNI %q: wrong certificate", tt.sni)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/webdav"
+)
+
+// webdavReadMethods 只读 WebDAV 方法，开启 -webdav 即可使用
+var webdavReadMethods = map[string]bool{
+	http.MethodOptions: true,
+	"PROPFIND":         true,
+}
+
+// webdavWriteMethods 修改文件的 WebDAV 方法，还需要开启 -upload
+//
+// PUT 也交给 WebDAV 处理器，以便检查其他客户端持有的锁；写入方式与覆盖规则与浏览器上传相同。
+// POST 仍由 handleUpload 处理。
+var webdavWriteMethods = map[string]bool{
+	http.MethodPut:    true,
+	"MKCOL":           true,
+	"COPY":            true,
+	"MOVE":            true,
+	"LOCK":            true,
+	"UNLOCK":          true,
+	"PROPPATCH":       true,
+	http.MethodDelete: true,
+}
+
+// newWebDAVHandler 创建以共享目录为根的 WebDAV 处理器
+func newWebDAVHandler(opt HandlerOptions) *webdav.Handler {
+	return &webdav.Handler{
+		FileSystem: &webdavFS{dir: webdav.Dir(opt.Root), opt: opt},
+		LockSystem: webdav.NewMemLS(),
+	}
+}
+
+// handleWebDAV 处理 WebDAV 请求，返回 false 表示交给普通文件服务处理
+//
+// GET / HEAD 仍由 http.FileServer 与目录列表处理，浏览器访问不受影响。
+func handleWebDAV(w http.ResponseWriter, r *http.Request, dav *webdav.Handler, opt HandlerOptions) bool {
+	switch {
+	case webdavReadMethods[r.Method]:
+	case webdavWriteMethods[r.Method]:
+		if !opt.Upload {
+			http.Error(w, "WebDAV is read-only, start hserve with -upload to allow changes", http.StatusForbidden)
+			return true
+		}
+		if !opt.AllowOverwrite {
+			if r.Method == http.MethodDelete {
+				http.Error(w, "Deleting requires -upload-overwrite", http.StatusForbidden)
+				return true
+			}
+			// 目标已存在时 COPY / MOVE 返回 412
+			r.Header.Set("Overwrite", "F")
+		}
+		if r.Method == http.MethodDelete && hasRestrictedDescendant(path.Clean("/"+r.URL.Path), opt) {
+			http.Error(w, "Directory contains hidden or restricted files", http.StatusForbidden)
+			return true
+		}
+	default:
+		return false
+	}
+
+	if r.Method == http.MethodPut {
+		var ok bool
+		if r, ok = prepareWebDAVPut(w, r, opt); !ok {
+			return true
+		}
+	}
+
+	dav.ServeHTTP(w, r)
+	return true
+}
+
+// webdavPutKey 请求上下文中保存 PUT 请求体的键
+type webdavPutKey struct{}
+
+// webdavPutBody 记录读取错误的请求体，上传中断时不提交临时文件
+type webdavPutBody struct {
+	io.ReadCloser
+	err error
+}
+
+// Read 实现 io.Reader
+func (b *webdavPutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+// prepareWebDAVPut 检查 PUT 目标，并把请求体放入上下文供 webdavFS.OpenFile 创建原子写入的文件
+func prepareWebDAVPut(w http.ResponseWriter, r *http.Request, opt HandlerOptions) (*http.Request, bool) {
+	urlPath := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") || urlPath == "/" {
+		http.Error(w, "PUT requires a file path", http.StatusBadRequest)
+		return nil, false
+	}
+	if info, err := os.Stat(filepath.Join(opt.Root, filepath.FromSlash(urlPath))); err == nil {
+		if info.IsDir() || !opt.AllowOverwrite {
+			http.Error(w, "File already exists", http.StatusConflict)
+			return nil, false
+		}
+	}
+
+	body := &webdavPutBody{ReadCloser: uploadBody(w, r.Body)}
+	r.Body = body
+	return r.WithContext(context.WithValue(r.Context(), webdavPutKey{}, body)), true
+}
+
+// webdavFS 对 webdav.Dir 的每次访问执行与文件服务相同的检查
+//
+// 包括路径限制在共享目录内（解析符号链接）、隐藏文件与 -paths 白名单；
+// COPY / MOVE 的目标地址也经过这里检查。不允许访问的路径按不存在处理。
+type webdavFS struct {
+	dir webdav.Dir
+	opt HandlerOptions
+}
+
+// allowed 检查路径是否允许访问
+func (fs *webdavFS) allowed(name string) bool {
+	return isRequestAllowed(name, fs.opt.Root, fs.opt.Paths, len(fs.opt.Paths) > 0)
+}
+
+// Mkdir 实现 webdav.FileSystem
+func (fs *webdavFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	if !fs.allowed(name) {
+		return os.ErrPermission
+	}
+	return fs.dir.Mkdir(ctx, name, perm)
+}
+
+// OpenFile 实现 webdav.FileSystem
+func (fs *webdavFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if !fs.allowed(name) {
+		return nil, os.ErrNotExist
+	}
+	if body, ok := ctx.Value(webdavPutKey{}).(*webdavPutBody); ok && flag&os.O_CREATE != 0 {
+		return newWebDAVUploadFile(fs.opt.Root, name, body, fs.opt.AllowOverwrite)
+	}
+
+	f, err := fs.dir.OpenFile(ctx, name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &webdavFile{File: f, name: name, fs: fs}, nil
+}
+
+// RemoveAll 实现 webdav.FileSystem
+//
+// 目录中含有隐藏文件或不允许访问的路径时拒绝删除：客户端看不到这些文件，
+// 不能替用户连同 .git、.ssh 等一起删掉。MOVE / COPY 覆盖已有目录时同样经过这里。
+func (fs *webdavFS) RemoveAll(ctx context.Context, name string) error {
+	// 不允许删除共享目录本身
+	if path.Clean("/"+name) == "/" || !fs.allowed(name) || hasRestrictedDescendant(name, fs.opt) {
+		return os.ErrPermission
+	}
+	return fs.dir.RemoveAll(ctx, name)
+}
+
+// hasRestrictedDescendant 检查目录中是否有隐藏文件或不允许访问的路径，不是目录时返回 false
+//
+// 不跟随符号链接；指向共享目录之外的链接本身也算作不允许访问的路径。
+func hasRestrictedDescendant(urlPath string, opt HandlerOptions) bool {
+	dir := filepath.Join(opt.Root, filepath.FromSlash(path.Clean("/"+urlPath)))
+	if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+		return false
+	}
+
+	restricted := false
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(opt.Root, p)
+		if err != nil {
+			return err
+		}
+		if !isRequestAllowed("/"+filepath.ToSlash(rel), opt.Root, opt.Paths, len(opt.Paths) > 0) {
+			restricted = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	// 无法完整检查（例如没有读取权限）时同样拒绝
+	return restricted || err != nil
+}
+
+// Rename 实现 webdav.FileSystem
+func (fs *webdavFS) Rename(ctx context.Context, oldName, newName string) error {
+	if !fs.allowed(oldName) || !fs.allowed(newName) {
+		return os.ErrPermission
+	}
+	return fs.dir.Rename(ctx, oldName, newName)
+}
+
+// Stat 实现 webdav.FileSystem
+func (fs *webdavFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	if !fs.allowed(name) {
+		return nil, os.ErrNotExist
+	}
+	return fs.dir.Stat(ctx, name)
+}
+
+// webdavFile 过滤目录内容的 webdav.File
+type webdavFile struct {
+	webdav.File
+	name string
+	fs   *webdavFS
+}
+
+// Readdir 实现 webdav.File，隐藏文件与不允许访问的路径不会出现在 PROPFIND 结果中
+func (f *webdavFile) Readdir(count int) ([]os.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+
+	filtered := infos[:0]
+	for _, info := range infos {
+		if f.fs.allowed(path.Join(f.name, info.Name())) {
+			filtered = append(filtered, info)
+		}
+	}
+	return filtered, err
+}
+
+// webdavUploadFile PUT 写入的文件：先写入同目录下的临时文件，关闭时再移动到目标位置
+//
+// 与 saveUpload 相同，中断的上传不会留下写了一半的文件。
+type webdavUploadFile struct {
+	*os.File
+	target    string
+	overwrite bool
+	body      *webdavPutBody
+	err       error // 写入过程中的错误
+}
+
+// newWebDAVUploadFile 在目标目录中创建临时文件
+func newWebDAVUploadFile(root, name string, body *webdavPutBody, overwrite bool) (webdav.File, error) {
+	target := filepath.Join(root, filepath.FromSlash(path.Clean("/"+name)))
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".hserve-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &webdavUploadFile{File: tmp, target: target, overwrite: overwrite, body: body}, nil
+}
+
+// Write 实现 io.Writer
+func (f *webdavUploadFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	if err != nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// ReadFrom 实现 io.ReaderFrom，io.Copy 会优先使用它而不是 Write
+func (f *webdavUploadFile) ReadFrom(r io.Reader) (int64, error) {
+	n, err := f.File.ReadFrom(r)
+	if err != nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// Close 实现 io.Closer，请求体完整且写入成功时才移动到目标位置
+func (f *webdavUploadFile) Close() error {
+	defer os.Remove(f.Name())
+
+	syncErr := f.File.Sync()
+	if err := errors.Join(f.body.err, f.err, syncErr, f.File.Close()); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return commitUpload(f.Name(), f.target, f.overwrite)
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWebDAVDeleteRestrictedDescendants(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		link    string // 指向共享目录之外的符号链接
+		overlay bool   // 覆盖已有目录（MOVE）而不是 DELETE
+		want    int
+	}{
+		{"plain directory", []string{"dir/a.txt", "dir/sub/b.txt"}, "", false, http.StatusNoContent},
+		{"hidden file", []string{"dir/a.txt", "dir/.ssh/id_ed25519"}, "", false, http.StatusForbidden},
+		{"nested hidden file", []string{"dir/sub/.git/config"}, "", false, http.StatusForbidden},
+		{"link outside share", []string{"dir/a.txt"}, "dir/escape", false, http.StatusForbidden},
+		{"move over hidden file", []string{"dir/.git/config", "src/a.txt"}, "", true, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRoot(t)
+			for _, f := range tt.files {
+				writeTestFile(t, root, f, "x")
+			}
+			if tt.link != "" {
+				mustSymlink(t, newTestRoot(t), filepath.Join(root, tt.link))
+			}
+			handler, _ := newWebDAVTest(t, HandlerOptions{Root: root, Upload: true, AllowOverwrite: true})
+
+			var rec *httptest.ResponseRecorder
+			if tt.overlay {
+				rec = serveTestRequest(handler, "MOVE", "/src/", map[string]string{
+					"Destination": "http://example.com/dir/",
+					"Overwrite":   "T",
+				})
+			} else {
+				rec = serveTestRequest(handler, http.MethodDelete, "/dir/", nil)
+			}
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusNoContent {
+				return
+			}
+			for _, f := range tt.files {
+				if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(f))); err != nil {
+					t.Fatalf("%s was removed: %v", f, err)
+				}
+			}
+		})
+	}
+}
+
+// lockBody 排他写锁的 LOCK 请求体
+const lockBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockinfo>`
+
+func TestWebDAVPutHonorsLocks(t *testing.T) {
+	handler, root := newWebDAVTest(t, HandlerOptions{Upload: true, AllowOverwrite: true})
+	writeTestFile(t, root, "doc.txt", "original")
+
+	req := httptest.NewRequest("LOCK", "/doc.txt", strings.NewReader(lockBody))
+	req.Header.Set("Timeout", "Second-60")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("LOCK = %d, want 200", rec.Code)
+	}
+	token := rec.Header().Get("Lock-Token")
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+		content string
+	}{
+		{"other client", nil, http.StatusLocked, "original"},
+		{"wrong token", map[string]string{"If": "(<opaquelocktoken:wrong>)"}, http.StatusPreconditionFailed, "original"},
+		{"lock holder", map[string]string{"If": "(" + token + ")"}, http.StatusCreated, "updated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/doc.txt", strings.NewReader("updated"))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("PUT = %d, want %d", rec.Code, tt.want)
+			}
+			if got, _ := os.ReadFile(filepath.Join(root, "doc.txt")); string(got) != tt.content {
+				t.Fatalf("content = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestWebDAVPut(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		body      io.Reader
+		overwrite bool
+		want      int
+		content   string // 为空表示目标文件不应被修改
+	}{
+		{"new file", "/new.txt", strings.NewReader("data"), false, http.StatusCreated, "data"},
+		{"existing file", "/exists.txt", strings.NewReader("data"), false, http.StatusConflict, ""},
+		{"overwrite", "/exists.txt", strings.NewReader("data"), true, http.StatusCreated, "data"},
+		{"hidden file", "/.env", strings.NewReader("data"), false, http.StatusForbidden, ""},
+		{"missing dir", "/nodir/a.txt", strings.NewReader("data"), false, http.StatusConflict, ""},
+		{"interrupted", "/new.txt", io.MultiReader(strings.NewReader("da"), errReader{}), false, http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, root := newWebDAVTest(t, HandlerOptions{Upload: true, AllowOverwrite: tt.overwrite})
+			writeTestFile(t, root, "exists.txt", "old")
+
+			req := httptest.NewRequest(http.MethodPut, tt.target, tt.body)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("PUT = %d, want %d", rec.Code, tt.want)
+			}
+
+			got, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(tt.target)))
+			switch {
+			case tt.content != "" && string(got) != tt.content:
+				t.Fatalf("content = %q, want %q", got, tt.content)
+			case tt.content == "" && tt.target == "/exists.txt" && string(got) != "old":
+				t.Fatalf("existing file changed to %q", got)
+			case tt.content == "" && tt.target != "/exists.txt" && err == nil:
+				t.Fatalf("%s was created", tt.target)
+			}
+			assertNoTempFiles(t, root)
+		})
+	}
+}
+
+func TestWebDAVReadOnly(t *testing.T) {
+	handler, root := newWebDAVTest(t, HandlerOptions{})
+	writeTestFile(t, root, "a.txt", "x")
+
+	for _, method := range []string{http.MethodPut, "MKCOL", "COPY", "MOVE", "LOCK", "PROPPATCH", http.MethodDelete} {
+		if rec := serveTestRequest(handler, method, "/a.txt", nil); rec.Code != http.StatusForbidden {
+			t.Errorf("%s = %d, want 403", method, rec.Code)
+		}
+	}
+	if rec := serveTestRequest(handler, "PROPFIND", "/", map[string]string{"Depth": "1"}); rec.Code != http.StatusMultiStatus {
+		t.Errorf("PROPFIND = %d, want 207", rec.Code)
+	}
+}
+
+func TestWebDAVPropfindFiltering(t *testing.T) {
+	root := newTestRoot(t)
+	writeTestFile(t, root, "pub/a.txt", "x")
+	writeTestFile(t, root, "pub/.secret", "x")
+	writeTestFile(t, root, "private/b.txt", "x")
+	writeTestFile(t, root, ".git/config", "x")
+	mustSymlink(t, newTestRoot(t), filepath.Join(root, "pub", "escape"))
+
+	tests := []struct {
+		name    string
+		paths   []string
+		target  string
+		want    int
+		visible []string
+		hidden  []string
+	}{
+		{"root", nil, "/", http.StatusMultiStatus, []string{"/pub/", "/private/"}, []string{".git"}},
+		{"subdir", nil, "/pub/", http.StatusMultiStatus, []string{"/pub/a.txt"}, []string{".secret", "escape"}},
+		{"allowlist", []string{"pub"}, "/", http.StatusMultiStatus, []string{"/pub/"}, []string{"private", ".git"}},
+		{"outside allowlist", []string{"pub"}, "/private/", http.StatusForbidden, nil, nil},
+		{"hidden path", nil, "/.git/", http.StatusForbidden, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			for _, p := range tt.paths {
+				paths = append(paths, filepath.Join(root, p))
+			}
+			handler, _ := newWebDAVTest(t, HandlerOptions{Root: root, Paths: paths})
+
+			rec := serveTestRequest(handler, "PROPFIND", tt.target, map[string]string{"Depth": "1"})
+			if rec.Code != tt.want {
+				t.Fatalf("PROPFIND = %d, want %d", rec.Code, tt.want)
+			}
+			body := rec.Body.String()
+			for _, v := range tt.visible {
+				if !strings.Contains(body, "<D:href>"+v+"</D:href>") {
+					t.Errorf("%s missing from response", v)
+				}
+			}
+			for _, h := range tt.hidden {
+				if strings.Contains(body, h) {
+					t.Errorf("%s listed in response", h)
+				}
+			}
+		})
+	}
+}
+
+func TestWebDAVCopyMoveConfinement(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		destination string
+		overwrite   bool
+		want        int
+	}{
+		{"copy", "COPY", "/b.txt", false, http.StatusCreated},
+		{"move", "MOVE", "/b.txt", false, http.StatusCreated},
+		{"copy to hidden", "COPY", "/.b.txt", false, http.StatusConflict},
+		{"move into link outside", "MOVE", "/escape/b.txt", false, http.StatusForbidden},
+		{"copy over existing", "COPY", "/exists.txt", false, http.StatusPreconditionFailed},
+		{"copy over existing with overwrite", "COPY", "/exists.txt", true, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, root := newWebDAVTest(t, HandlerOptions{Upload: true, AllowOverwrite: tt.overwrite})
+			writeTestFile(t, root, "a.txt", "x")
+			writeTestFile(t, root, "exists.txt", "old")
+			outside := newTestRoot(t)
+			mustSymlink(t, outside, filepath.Join(root, "escape"))
+
+			rec := serveTestRequest(handler, tt.method, "/a.txt", map[string]string{
+				"Destination": "http://example.com" + tt.destination,
+				"Overwrite":   "T",
+			})
+			if rec.Code != tt.want {
+				t.Fatalf("%s = %d, want %d", tt.method, rec.Code, tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(outside, "b.txt")); err == nil {
+				t.Fatal("file written outside the share")
+			}
+			if _, err := os.Stat(filepath.Join(root, ".b.txt")); err == nil {
+				t.Fatal("hidden file created")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package qrcode
+
+// 掩码评分规则的惩罚权重
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// penalty 按标准的四条规则计算当前矩阵的惩罚分
+func (c *Code) penalty() int {
+	result := 0
+
+	// 规则 1 与规则 3：逐行、逐列检查
+	for y := 0; y < c.size; y++ {
+		result += c.linePenalty(func(i int) bool { return c.modules[y][i] })
+	}
+	for x := 0; x < c.size; x++ {
+		result += c.linePenalty(func(i int) bool { return c.modules[i][x] })
+	}
+
+	// 规则 2：2x2 同色块
+	for y := 0; y < c.size-1; y++ {
+		for x := 0; x < c.size-1; x++ {
+			color := c.modules[y][x]
+			if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+				result += penaltyN2
+			}
+		}
+	}
+
+	// 规则 4：深色模块比例偏离 50%
+	dark := 0
+	for _, row := range c.modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	total := c.size * c.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyN4
+
+	return result
+}
+
+// linePenalty 计算一行（或一列）中连续同色与类定位图形的惩罚分
+func (c *Code) linePenalty(at func(int) bool) int {
+	result := 0
+
+	runColor := false
+	runLen := 0
+	for i := 0; i < c.size; i++ {
+		if at(i) == runColor {
+			runLen++
+			if runLen == 5 {
+				result += penaltyN1
+			} else if runLen > 5 {
+				result++
+			}
+		} else {
+			runColor = at(i)
+			runLen = 1
+		}
+	}
+
+	// 1:1:3:1:1 图形，两侧任一侧有 4 个浅色模块（越界视为浅色）
+	dark := func(i int) bool { return i >= 0 && i < c.size && at(i) }
+	for i := -4; i < c.size; i++ {
+		if dark(i) && !dark(i+1) && dark(i+2) && dark(i+3) && dark(i+4) && !dark(i+5) && dark(i+6) {
+			before := !dark(i-1) && !dark(i-2) && !dark(i-3) && !dark(i-4)
+			after := !dark(i+7) && !dark(i+8) && !dark(i+9) && !dark(i+10)
+			if before || after {
+				result += penaltyN3
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,197 @@
+// Package qrcode 实现在终端中显示访问地址所需的最小二维码编码器
+//
+// 仅支持字节模式与 M 级纠错，版本 1～10（最多 213 字节），足以容纳 URL。
+package qrcode
+
+import "errors"
+
+// maxVersion 支持的最高版本
+const maxVersion = 10
+
+// M 级纠错下各版本每块纠错码字数与块数（下标为版本号）
+var (
+	eccCodewordsPerBlock = [maxVersion + 1]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26}
+	numErrorCorrectBlock = [maxVersion + 1]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5}
+)
+
+// eclFormatBits M 级纠错在格式信息中的编码
+const eclFormatBits = 0
+
+// ErrTooLong 内容超出支持的最大容量
+var ErrTooLong = errors.New("二维码内容过长")
+
+// Code 二维码矩阵
+type Code struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Size 返回二维码边长（模块数）
+func (c *Code) Size() int {
+	return c.size
+}
+
+// Dark 返回 (x, y) 处是否为深色模块，越界视为浅色
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.size && y < c.size && c.modules[y][x]
+}
+
+// Encode 将文本编码为二维码
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+
+	version := 1
+	for ; version <= maxVersion; version++ {
+		if len(data) <= dataCapacity(version) {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, ErrTooLong
+	}
+
+	codewords := addErrorCorrection(encodeData(data, version), version)
+
+	c := newCode(version)
+	c.drawFunctionPatterns(version)
+	c.drawCodewords(codewords)
+
+	// 选择惩罚分最低的掩码
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // 异或两次即撤销
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+
+	return c, nil
+}
+
+// newCode 创建空白矩阵
+func newCode(version int) *Code {
+	size := version*4 + 17
+	c := &Code{
+		size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.isFunction[i] = make([]bool, size)
+	}
+	return c
+}
+
+// rawDataModules 返回版本中可用于数据与纠错的模块数
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// dataCodewords 返回版本可容纳的数据码字数
+func dataCodewords(version int) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[version]*numErrorCorrectBlock[version]
+}
+
+// charCountBits 返回字节模式下字符计数指示符的位数
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// dataCapacity 返回版本在字节模式下可容纳的字节数
+func dataCapacity(version int) int {
+	return (dataCodewords(version)*8 - 4 - charCountBits(version)) / 8
+}
+
+// encodeData 按字节模式编码数据并填充到数据码字长度
+func encodeData(data []byte, version int) []byte {
+	var bb bitBuffer
+	bb.append(0x4, 4) // 字节模式
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+
+	capacityBits := dataCodewords(version) * 8
+	terminator := capacityBits - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacityBits; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	result := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			result[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+	return result
+}
+
+// addErrorCorrection 分块计算纠错码并交错排列
+func addErrorCorrection(data []byte, version int) []byte {
+	numBlocks := numErrorCorrectBlock[version]
+	eccLen := eccCodewordsPerBlock[version]
+	rawCodewords := rawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLen := rawCodewords/numBlocks - eccLen
+
+	divisor := reedSolomonDivisor(eccLen)
+	dataBlocks := make([][]byte, numBlocks)
+	eccBlocks := make([][]byte, numBlocks)
+	offset := 0
+	for i := 0; i < numBlocks; i++ {
+		n := shortDataLen
+		if i >= numShortBlocks {
+			n++
+		}
+		dataBlocks[i] = data[offset : offset+n]
+		eccBlocks[i] = reedSolomonRemainder(dataBlocks[i], divisor)
+		offset += n
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := 0; i <= shortDataLen; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for _, block := range eccBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// bitBuffer 按位追加的缓冲区
+type bitBuffer []bool
+
+// append 追加 val 的低 n 位（高位在前）
+func (bb *bitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, (val>>uint(i))&1 != 0)
+	}
+}
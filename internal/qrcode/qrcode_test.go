@@ -0,0 +1,212 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// matrixString 将矩阵转换为文本，深色为 #，浅色为 .
+func matrixString(c *Code) string {
+	var sb strings.Builder
+	for y := 0; y < c.Size(); y++ {
+		for x := 0; x < c.Size(); x++ {
+			if c.Dark(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// TestEncodeKnownAnswer 与参考编码器的输出逐模块比较
+//
+// testdata 中的矩阵由 github.com/skip2/go-qrcode 以 M 级纠错生成（不含留白），
+// 参考编码器选择的掩码与本实现相同（版本 1、7、10 分别为 0、4、2）。
+func TestEncodeKnownAnswer(t *testing.T) {
+	tests := []struct {
+		version int
+		text    string
+	}{
+		{1, "http://a.test/"},
+		{7, "https://files.example.lan/share/documents/reports/quarterly/summary-final-version.pdf?token=abcdefghijklmnopqrstuvwx"},
+		{10, "https://hserve.example.lan/share/projects/website/assets/images/gallery/summer-holiday/beach/sunset-over-the-ocean-panorama-high-resolution.jpg?download=true&token=abcdefghijklmnopqrstuvwxyzabcdefghijklmno"},
+	}
+
+	for _, tt := range tests {
+		want, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("version%d.txt", tt.version)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := Encode(tt.text)
+		if err != nil {
+			t.Fatalf("version %d: %v", tt.version, err)
+		}
+		if c.Size() != tt.version*4+17 {
+			t.Fatalf("version %d: size = %d, want %d", tt.version, c.Size(), tt.version*4+17)
+		}
+		if got := matrixString(c); got != string(want) {
+			t.Errorf("version %d: matrix differs from reference\ngot:\n%s\nwant:\n%s", tt.version, got, want)
+		}
+	}
+}
+
+func TestEncodeCapacity(t *testing.T) {
+	tests := []struct {
+		length  int
+		version int
+	}{
+		{14, 1}, {15, 2}, {106, 6}, {107, 7}, {122, 7}, {123, 8}, {180, 9}, {181, 10}, {213, 10},
+	}
+	for _, tt := range tests {
+		c, err := Encode(strings.Repeat("a", tt.length))
+		if err != nil {
+			t.Fatalf("length %d: %v", tt.length, err)
+		}
+		if got := (c.Size() - 17) / 4; got != tt.version {
+			t.Errorf("length %d: version = %d, want %d", tt.length, got, tt.version)
+		}
+	}
+	if _, err := Encode(strings.Repeat("a", 214)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("length 214: err = %v, want ErrTooLong", err)
+	}
+}
+
+// TestFormatBits 格式信息与 ISO/IEC 18004 表 C.1 中 M 级纠错的取值比较
+func TestFormatBits(t *testing.T) {
+	want := [8]int{
+		0b101010000010010,
+		0b101000100100101,
+		0b101111001111100,
+		0b101101101001011,
+		0b100010111111001,
+		0b100000011001110,
+		0b100111110010111,
+		0b100101010100000,
+	}
+
+	for mask, bits := range want {
+		c := newCode(1)
+		c.drawFormatBits(mask)
+
+		// 第一份：左上角定位图案周围
+		var first, second int
+		var positions [15][2]int
+		for i := 0; i <= 5; i++ {
+			positions[i] = [2]int{8, i}
+		}
+		positions[6] = [2]int{8, 7}
+		positions[7] = [2]int{8, 8}
+		positions[8] = [2]int{7, 8}
+		for i := 9; i < 15; i++ {
+			positions[i] = [2]int{14 - i, 8}
+		}
+		for i, p := range positions {
+			if c.Dark(p[0], p[1]) {
+				first |= 1 << i
+			}
+		}
+		// 第二份：右上角与左下角
+		for i := 0; i < 8; i++ {
+			if c.Dark(c.size-1-i, 8) {
+				second |= 1 << i
+			}
+		}
+		for i := 8; i < 15; i++ {
+			if c.Dark(8, c.size-15+i) {
+				second |= 1 << i
+			}
+		}
+
+		if first != bits || second != bits {
+			t.Errorf("mask %d: format bits = %015b / %015b, want %015b", mask, first, second, bits)
+		}
+		if !c.Dark(8, c.size-8) {
+			t.Errorf("mask %d: dark module missing", mask)
+		}
+	}
+}
+
+// TestVersionBits 版本信息与 ISO/IEC 18004 表 D.1 的取值比较
+func TestVersionBits(t *testing.T) {
+	want := map[int]int{
+		7:  0x07C94,
+		8:  0x085BC,
+		9:  0x09A99,
+		10: 0x0A4D3,
+	}
+
+	for version, bits := range want {
+		c := newCode(version)
+		c.drawVersionBits(version)
+
+		var bottomLeft, topRight int
+		for i := 0; i < 18; i++ {
+			a, b := c.size-11+i%3, i/3
+			if c.Dark(a, b) {
+				topRight |= 1 << i
+			}
+			if c.Dark(b, a) {
+				bottomLeft |= 1 << i
+			}
+		}
+		if topRight != bits || bottomLeft != bits {
+			t.Errorf("version %d: version bits = %018b / %018b, want %018b", version, topRight, bottomLeft, bits)
+		}
+	}
+
+	// 版本 6 及以下没有版本信息
+	c := newCode(6)
+	c.drawVersionBits(6)
+	for y := range c.modules {
+		for x := range c.modules[y] {
+			if c.modules[y][x] {
+				t.Fatalf("version 6: unexpected module at (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+// TestReedSolomon 使用 "HELLO WORLD"（版本 1-M，字母数字模式）的标准示例数据
+func TestReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := reedSolomonRemainder(data, reedSolomonDivisor(len(want)))
+	if !bytes.Equal(got, want) {
+		t.Fatalf("parity = %v, want %v", got, want)
+	}
+
+	// 数据加纠错码组成的码字多项式能被生成多项式整除
+	codeword := append(append([]byte{}, data...), got...)
+	if rem := reedSolomonRemainder(codeword, reedSolomonDivisor(len(want))); !bytes.Equal(rem, make([]byte, len(want))) {
+		t.Fatalf("codeword remainder = %v, want zeros", rem)
+	}
+}
+
+func TestGFMultiply(t *testing.T) {
+	tests := []struct {
+		x, y, want byte
+	}{
+		{0, 0x53, 0},
+		{1, 0x53, 0x53},
+		{0x02, 0x80, 0x1D}, // x^8 约化为 x^4 + x^3 + x^2 + 1
+		{0x80, 0x04, 0x3A}, // α^7 · α^2 = α^9
+		{0x80, 0x20, 0xCD}, // α^7 · α^5 = α^12
+	}
+	for _, tt := range tests {
+		if got := gfMultiply(tt.x, tt.y); got != tt.want {
+			t.Errorf("gfMultiply(%#x, %#x) = %#x, want %#x", tt.x, tt.y, got, tt.want)
+		}
+		if got := gfMultiply(tt.y, tt.x); got != tt.want {
+			t.Errorf("gfMultiply(%#x, %#x) = %#x, want %#x", tt.y, tt.x, got, tt.want)
+		}
+	}
+}
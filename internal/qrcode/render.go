@@ -0,0 +1,35 @@
+package qrcode
+
+import "strings"
+
+// quietZone 四周留白的模块数，ISO/IEC 18004 要求至少 4 个模块，过窄时部分扫码器无法识别
+const quietZone = 4
+
+// Terminal 使用 Unicode 半高方块渲染二维码，每个字符表示上下两个模块
+//
+// 浅色模块绘制为方块、深色模块留空，适合深色背景的终端；
+// 四周保留空白区域，便于手机扫码识别。
+func (c *Code) Terminal() string {
+	var sb strings.Builder
+	for y := -quietZone; y < c.size+quietZone; y += 2 {
+		for x := -quietZone; x < c.size+quietZone; x++ {
+			sb.WriteString(halfBlock(!c.Dark(x, y), !c.Dark(x, y+1)))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// halfBlock 返回上下两个模块对应的字符
+func halfBlock(top, bottom bool) string {
+	switch {
+	case top && bottom:
+		return "█"
+	case top:
+		return "▀"
+	case bottom:
+		return "▄"
+	default:
+		return " "
+	}
+}
@@ -0,0 +1,13 @@
+//go:build go1.25
+
+package tls
+
+import "crypto/tls"
+
+// NegotiatedCurve 返回连接协商使用的密钥交换算法名称，例如 X25519MLKEM768
+func NegotiatedCurve(cs *tls.ConnectionState) string {
+	if cs == nil || cs.CurveID == 0 {
+		return ""
+	}
+	return cs.CurveID.String()
+}
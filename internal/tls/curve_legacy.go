@@ -0,0 +1,10 @@
+//go:build !go1.25
+
+package tls
+
+import "crypto/tls"
+
+// NegotiatedCurve Go 1.25 以下的工具链无法获取协商的密钥交换算法，始终返回空字符串
+func NegotiatedCurve(cs *tls.ConnectionState) string {
+	return ""
+}
@@ -1,27 +1,43 @@
 // Package tls 包含 TLS 安全策略配置
 package tls
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+	"fmt"
+)
 
 // DefaultConfig 返回安全的 TLS 配置
 func DefaultConfig(cert tls.Certificate) *tls.Config {
 	// 创建并配置新的TLS配置
 	config := createBaseTLSConfig(cert)
-	
-	// 设置密码套件
-	config.CipherSuites = getSecureCipherSuites()
-	
-	// 设置曲线偏好
-	config.CurvePreferences = getSecureCurvePreferences()
-	
+
+	// 应用默认安全策略
+	DefaultPolicy().apply(config)
+
+	return config
+}
+
+// ReloadableConfig 返回通过回调获取证书的 TLS 配置，用于证书热加载
+func ReloadableConfig(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error), policy Policy) *tls.Config {
+	config := newBaseTLSConfig()
+	config.GetCertificate = getCertificate
+
+	// 应用指定的安全策略
+	policy.apply(config)
+
 	return config
 }
 
 // createBaseTLSConfig 创建基础TLS配置
 func createBaseTLSConfig(cert tls.Certificate) *tls.Config {
+	config := newBaseTLSConfig()
+	config.Certificates = []tls.Certificate{cert}
+	return config
+}
+
+// newBaseTLSConfig 创建不含证书的基础TLS配置，协议版本由策略设置
+func newBaseTLSConfig() *tls.Config {
 	return &tls.Config{
-		Certificates:             []tls.Certificate{cert},
-		MinVersion:               tls.VersionTLS12,
 		PreferServerCipherSuites: true,
 	}
 }
@@ -45,3 +61,20 @@ func getSecureCurvePreferences() []tls.CurveID {
 		tls.CurveP256,
 	}
 }
+
+// ParseClientAuth 解析客户端证书验证模式
+//
+// require 要求客户端必须提供由受信任 CA 签发的证书；
+// verify-if-given 允许不带证书访问，但提供的证书必须有效。
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	case "verify-if-given":
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("未知的客户端验证模式: %s（可选: require|verify-if-given）", mode)
+	}
+}
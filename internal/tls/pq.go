@@ -0,0 +1,6 @@
+package tls
+
+import "crypto/tls"
+
+// hybridKeyExchanges 支持的后量子混合密钥交换（go.mod 要求 Go 1.24+）
+var hybridKeyExchanges = []tls.CurveID{tls.X25519MLKEM768}
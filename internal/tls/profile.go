@@ -0,0 +1,290 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// Profile TLS 策略档位
+type Profile string
+
+const (
+	ProfileModern       Profile = "modern"       // 仅 TLS 1.3，优先后量子混合密钥交换
+	ProfileIntermediate Profile = "intermediate" // TLS 1.2+，仅 AEAD 套件，优先后量子混合密钥交换
+	ProfileCompat       Profile = "compat"       // TLS 1.0+，附加 CBC 套件，兼容 Android 4.x 等旧设备
+
+	// DefaultProfile 未指定时使用的策略档位
+	DefaultProfile = ProfileIntermediate
+)
+
+// Profiles 返回所有支持的策略档位
+func Profiles() []Profile {
+	return []Profile{ProfileModern, ProfileIntermediate, ProfileCompat}
+}
+
+// Policy TLS 协议版本、密码套件与曲线设置
+type Policy struct {
+	Profile          Profile
+	MinVersion       uint16
+	CipherSuites     []uint16 // 仅作用于 TLS 1.2 及以下，TLS 1.3 套件不可配置
+	CurvePreferences []tls.CurveID
+}
+
+// PolicyOptions 命令行中的策略设置，为空的字段使用档位默认值
+type PolicyOptions struct {
+	Profile      string
+	MinVersion   string // 1.0 | 1.1 | 1.2 | 1.3
+	CipherSuites string // 逗号分隔的套件名称，例如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	Curves       string // 逗号分隔的曲线名称，例如 X25519,P256
+}
+
+// DefaultPolicy 返回默认档位的策略
+func DefaultPolicy() Policy {
+	policy, _ := ProfilePolicy(DefaultProfile)
+	return policy
+}
+
+// ProfilePolicy 返回指定档位的策略
+func ProfilePolicy(p Profile) (Policy, error) {
+	switch p {
+	case ProfileModern:
+		return Policy{
+			Profile:          p,
+			MinVersion:       tls.VersionTLS13,
+			CurvePreferences: getHybridCurvePreferences(),
+		}, nil
+	case ProfileIntermediate:
+		return Policy{
+			Profile:          p,
+			MinVersion:       tls.VersionTLS12,
+			CipherSuites:     getSecureCipherSuites(),
+			CurvePreferences: getHybridCurvePreferences(),
+		}, nil
+	case ProfileCompat:
+		return Policy{
+			Profile:          p,
+			MinVersion:       tls.VersionTLS10,
+			CipherSuites:     append(getSecureCipherSuites(), getCompatCipherSuites()...),
+			CurvePreferences: append(getSecureCurvePreferences(), tls.CurveP384),
+		}, nil
+	default:
+		return Policy{}, fmt.Errorf("未知的 TLS 策略: %s（可选: %s）", p, joinProfiles())
+	}
+}
+
+// BuildPolicy 根据档位和覆盖项生成策略，名称无效时返回错误
+func BuildPolicy(opt PolicyOptions) (Policy, error) {
+	name := opt.Profile
+	if name == "" {
+		name = string(DefaultProfile)
+	}
+	policy, err := ProfilePolicy(Profile(strings.ToLower(name)))
+	if err != nil {
+		return Policy{}, err
+	}
+
+	if opt.MinVersion != "" {
+		if policy.MinVersion, err = ParseVersion(opt.MinVersion); err != nil {
+			return Policy{}, err
+		}
+	}
+	if opt.CipherSuites != "" {
+		if policy.CipherSuites, err = ParseCipherSuites(opt.CipherSuites); err != nil {
+			return Policy{}, err
+		}
+		if policy.MinVersion == tls.VersionTLS13 {
+			return Policy{}, fmt.Errorf("TLS 1.3 的密码套件不可配置，-tls-ciphers 仅在最低版本低于 1.3 时有效")
+		}
+	}
+	if opt.Curves != "" {
+		if policy.CurvePreferences, err = ParseCurves(opt.Curves); err != nil {
+			return Policy{}, err
+		}
+	}
+
+	return policy, nil
+}
+
+// Describe 返回策略的简要说明，用于启动信息
+func (p Policy) Describe() string {
+	desc := fmt.Sprintf("%s (TLS %s+)", p.Profile, VersionName(p.MinVersion))
+	for _, id := range p.CurvePreferences {
+		if isHybridKeyExchange(id) {
+			desc += "，后量子混合密钥交换: " + id.String()
+			break
+		}
+	}
+	return desc
+}
+
+// apply 将策略写入 TLS 配置
+func (p Policy) apply(config *tls.Config) {
+	config.MinVersion = p.MinVersion
+	config.CipherSuites = p.CipherSuites
+	config.CurvePreferences = p.CurvePreferences
+}
+
+// tlsVersions 支持的协议版本名称
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseVersion 解析协议版本，例如 1.2
+func ParseVersion(name string) (uint16, error) {
+	v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(name), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("未知的 TLS 版本: %s（可选: 1.0|1.1|1.2|1.3）", name)
+	}
+	return v, nil
+}
+
+// VersionName 返回协议版本名称，例如 1.2
+func VersionName(v uint16) string {
+	for name, version := range tlsVersions {
+		if version == v {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
+
+// ParseCipherSuites 解析逗号分隔的密码套件名称
+//
+// 只接受 TLS 1.0–1.2 套件；Go 不允许配置 TLS 1.3 套件，写了也不会生效，因此直接报错。
+func ParseCipherSuites(list string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	tls13 := make(map[string]bool)
+	for _, cs := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if isTLS13Only(cs) {
+			tls13[cs.Name] = true
+			continue
+		}
+		known[cs.Name] = cs.ID
+	}
+
+	var ids []uint16
+	for _, name := range splitList(list) {
+		if tls13[strings.ToUpper(name)] {
+			return nil, fmt.Errorf("TLS 1.3 的密码套件不可配置: %s（-tls-ciphers 只作用于 TLS 1.2 及以下）", name)
+		}
+		id, ok := known[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("未知的密码套件: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// isTLS13Only 检查密码套件是否只用于 TLS 1.3
+func isTLS13Only(cs *tls.CipherSuite) bool {
+	return len(cs.SupportedVersions) == 1 && cs.SupportedVersions[0] == tls.VersionTLS13
+}
+
+// tlsCurves 支持的曲线名称（含常见别名）
+var tlsCurves = map[string]tls.CurveID{
+	"x25519":    tls.X25519,
+	"p256":      tls.CurveP256,
+	"p-256":     tls.CurveP256,
+	"secp256r1": tls.CurveP256,
+	"p384":      tls.CurveP384,
+	"p-384":     tls.CurveP384,
+	"secp384r1": tls.CurveP384,
+	"p521":      tls.CurveP521,
+	"p-521":     tls.CurveP521,
+	"secp521r1": tls.CurveP521,
+}
+
+// ParseCurves 解析逗号分隔的曲线名称
+//
+// 工具链支持时也接受 X25519MLKEM768 等后量子混合密钥交换。
+func ParseCurves(list string) ([]tls.CurveID, error) {
+	var curves []tls.CurveID
+	for _, name := range splitList(list) {
+		id, ok := lookupCurve(name)
+		if !ok {
+			return nil, fmt.Errorf("未知的曲线: %s（可选: %s）", name, joinCurveNames())
+		}
+		curves = append(curves, id)
+	}
+	return curves, nil
+}
+
+// lookupCurve 按名称查找曲线或混合密钥交换
+func lookupCurve(name string) (tls.CurveID, bool) {
+	if id, ok := tlsCurves[strings.ToLower(name)]; ok {
+		return id, true
+	}
+	for _, id := range hybridKeyExchanges {
+		if strings.EqualFold(name, id.String()) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// joinCurveNames 返回以 | 分隔的可选曲线名称
+func joinCurveNames() string {
+	names := []string{"X25519", "P256", "P384", "P521"}
+	for _, id := range hybridKeyExchanges {
+		names = append(names, id.String())
+	}
+	return strings.Join(names, "|")
+}
+
+// isHybridKeyExchange 检查是否为后量子混合密钥交换
+func isHybridKeyExchange(id tls.CurveID) bool {
+	for _, h := range hybridKeyExchanges {
+		if h == id {
+			return true
+		}
+	}
+	return false
+}
+
+// getHybridCurvePreferences 获取优先后量子混合密钥交换的曲线偏好
+//
+// 工具链不支持时与 getSecureCurvePreferences 相同。
+func getHybridCurvePreferences() []tls.CurveID {
+	curves := append([]tls.CurveID{}, hybridKeyExchanges...)
+	return append(curves, getSecureCurvePreferences()...)
+}
+
+// getCompatCipherSuites 获取兼容旧设备的 CBC 密码套件
+//
+// Android 4.x 不支持 AEAD 套件，只能使用 AES-CBC；仅在 compat 档位启用。
+func getCompatCipherSuites() []uint16 {
+	return []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+		tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+		tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	}
+}
+
+// joinProfiles 返回以 | 分隔的档位列表
+func joinProfiles() string {
+	var names []string
+	for _, p := range Profiles() {
+		names = append(names, string(p))
+	}
+	return strings.Join(names, "|")
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
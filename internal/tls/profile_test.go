@@ -0,0 +1,35 @@
+package tls
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+)
+
+func TestParseCipherSuites(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []uint16
+		wantErr bool
+	}{
+		{"tls 1.2", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, false},
+		{"lower case", "tls_ecdhe_ecdsa_with_aes_128_gcm_sha256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			[]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, false},
+		{"tls 1.3", "TLS_AES_128_GCM_SHA256", nil, true},
+		{"mixed with tls 1.3", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_CHACHA20_POLY1305_SHA256", nil, true},
+		{"unknown", "TLS_FOO", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCipherSuites(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package certgen
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+)
+
+// certAuthority 用于签发服务器证书的 CA
+type certAuthority struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// prepareCA 返回用于签发的 CA，能复用已有 CA 时优先复用
+func prepareCA(newCA bool, kt KeyType, caCertPath, caKeyPath string) (*certAuthority, bool, error) {
+	if !newCA && CheckCertificateExists(caCertPath) {
+		if CheckCertificateExists(caKeyPath) {
+			ca, err := loadCA(caCertPath, caKeyPath)
+			if err != nil {
+				return nil, false, err
+			}
+			return ca, true, nil
+		}
+		fmt.Println("⚠️  未找到 CA 私钥:", caKeyPath)
+		fmt.Println("   将生成新的 CA，请在设备上重新安装 CA 证书")
+	}
+
+	ca, err := createCA(kt, caCertPath, caKeyPath)
+	if err != nil {
+		return nil, false, err
+	}
+	return ca, false, nil
+}
+
+// createCA 生成新的 CA 并保存证书与私钥
+func createCA(kt KeyType, caCertPath, caKeyPath string) (*certAuthority, error) {
+	caKey, err := generateCAKey(kt)
+	if err != nil {
+		return nil, err
+	}
+
+	caCertDER, err := generateCACertificate(caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	caCert, err := x509.ParseCertificate(caCertDER)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先写私钥，避免出现只有 CA 证书却没有私钥的状态
+	if err := writePrivateKey(caKeyPath, caKey); err != nil {
+		return nil, err
+	}
+	if err := writePem(caCertPath, "CERTIFICATE", caCertDER, 0644); err != nil {
+		return nil, err
+	}
+
+	return &certAuthority{cert: caCert, key: caKey}, nil
+}
+
+// loadCA 从磁盘加载已有 CA
+func loadCA(caCertPath, caKeyPath string) (*certAuthority, error) {
+	certDER, err := readPem(caCertPath, "CERTIFICATE")
+	if err != nil {
+		return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+	}
+	caCert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("解析 CA 证书失败: %w", err)
+	}
+
+	caKey, err := readPrivateKey(caKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取 CA 私钥失败: %w", err)
+	}
+
+	if !publicKeysEqual(caKey.Public(), caCert.PublicKey) {
+		return nil, fmt.Errorf("CA 私钥与 CA 证书不匹配: %s", caKeyPath)
+	}
+
+	return &certAuthority{cert: caCert, key: caKey}, nil
+}
@@ -1,56 +1,106 @@
 package certgen
 
 import (
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
-	"math/big"
-	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
+// Options 证书生成选项
+type Options struct {
+	Force   bool     // 强制重新签发服务器证书（默认沿用已有 CA）
+	NewCA   bool     // 重新生成 CA，已安装到设备上的旧 CA 将失效
+	SANs    []string // 额外写入服务器证书的 IP / 域名（支持 *.example.lan）
+	AutoSAN bool     // 自动加入本机网卡地址与主机名
+	KeyType KeyType  // 新生成私钥的类型，沿用已有 CA 时 CA 私钥类型不变
+	Days    int      // 服务器证书有效天数，0 表示使用 DefaultLeafDays
+}
+
+// DefaultLeafDays 服务器证书默认有效天数
+//
+// Apple 与 Chrome 拒绝有效期超过 398 天的服务器证书，默认值留出一天余量。
+const DefaultLeafDays = 397
+
+// caValidityYears CA 证书有效年数
+const caValidityYears = 10
+
+// clockSkewAllowance 证书生效时间向前回拨，容忍设备间的时钟误差
+const clockSkewAllowance = 5 * time.Minute
+
 // Generate 生成证书
-func Generate(force bool) error {
+//
+// 若已存在 CA 证书及其私钥，则直接使用该 CA 签发新的服务器证书，
+// 设备上已安装的 CA 无需重新安装；仅在 NewCA 或 CA 缺失时生成新的 CA。
+func Generate(opt Options) error {
 	certPath, keyPath := GetCertPaths()
 	caCertPath := GetCACertPath()
+	caKeyPath := GetCAKeyPath()
+
+	// 先校验名称，避免写入一半后才发现参数错误
+	names := opt.SANs
+	if opt.AutoSAN {
+		detected := detectLocalNames()
+		fmt.Println("🔎 自动检测到的证书名称:", strings.Join(detected, ", "))
+		names = append(append([]string{}, names...), detected...)
+	}
+	sans, err := buildSubjectAltNames(names)
+	if err != nil {
+		return err
+	}
+	if err := checkLeafDays(opt.Days); err != nil {
+		return err
+	}
 
 	// 检查证书是否存在
-	if shouldSkipGeneration(force, certPath, caCertPath) {
+	if shouldSkipGeneration(opt, certPath, caCertPath) {
 		fmt.Println("✅ 证书已存在，无需重新生成")
 		ShowInstructions(caCertPath)
 		return nil
 	}
 
 	// 生成证书
-	return generateAndSaveCertificates(certPath, keyPath, caCertPath)
+	return generateAndSaveCertificates(opt, sans, certPath, keyPath, caCertPath, caKeyPath)
 }
 
 // shouldSkipGeneration 检查是否应该跳过证书生成
-func shouldSkipGeneration(force bool, certPath, caCertPath string) bool {
-	return !force && CheckCertificateExists(certPath) && CheckCertificateExists(caCertPath)
+func shouldSkipGeneration(opt Options, certPath, caCertPath string) bool {
+	return !opt.Force && !opt.NewCA && !opt.AutoSAN && len(opt.SANs) == 0 && CheckCertificateExists(certPath) && CheckCertificateExists(caCertPath)
 }
 
 // generateAndSaveCertificates 生成并保存证书
-func generateAndSaveCertificates(certPath, keyPath, caCertPath string) error {
+func generateAndSaveCertificates(opt Options, sans subjectAltNames, certPath, keyPath, caCertPath, caKeyPath string) error {
 	if err := ensureCertDirectory(filepath.Dir(certPath)); err != nil {
 		return err
 	}
+	if err := ensureCertDirectory(filepath.Dir(caKeyPath)); err != nil {
+		return err
+	}
+
+	ca, reused, err := prepareCA(opt.NewCA, opt.KeyType, caCertPath, caKeyPath)
+	if err != nil {
+		return err
+	}
 
-	certData, err := createCertificateData()
+	certData, err := createCertificateData(ca, opt.KeyType, sans, opt.Days)
 	if err != nil {
 		return err
 	}
 
-	if err := saveCertificates(certData, certPath, keyPath, caCertPath); err != nil {
+	if err := saveCertificates(certData, certPath, keyPath); err != nil {
 		return err
 	}
 
 	fmt.Println("✅ 证书生成完成")
+	if reused {
+		fmt.Println("♻️  已使用现有 CA 签发服务器证书，设备上已安装的 CA 无需重新安装")
+	}
 	fmt.Println("💡 温馨提示: 请妥善保管您的证书文件")
 	ShowInstructions(caCertPath)
 	return nil
@@ -61,94 +111,64 @@ func ensureCertDirectory(dir string) error {
 	return os.MkdirAll(dir, 0755)
 }
 
-// certificateData 包含证书生成所需的所有数据
+// certificateData 包含服务器证书的所有数据
 type certificateData struct {
-	caKey       *rsa.PrivateKey
-	serverKey   *rsa.PrivateKey
-	caCertDER   []byte
+	serverKey     crypto.Signer
 	serverCertDER []byte
 }
 
-// createCertificateData 创建证书数据
-func createCertificateData() (certificateData, error) {
-	// 生成证书对
-	caKey, serverKey, err := generateCertificateKeys()
-	if err != nil {
-		return certificateData{}, err
-	}
-
-	// 生成 CA 证书
-	caCertDER, err := generateCACertificate(caKey)
+// createCertificateData 使用 CA 签发服务器证书
+func createCertificateData(ca *certAuthority, kt KeyType, sans subjectAltNames, days int) (certificateData, error) {
+	// 生成服务器私钥
+	serverKey, err := generateServerKey(kt)
 	if err != nil {
 		return certificateData{}, err
 	}
 
 	// 生成服务器证书
-	serverCertDER, err := generateServerCertificate(caKey, serverKey)
+	serverCertDER, err := generateServerCertificate(ca, serverKey, sans, days)
 	if err != nil {
 		return certificateData{}, err
 	}
 
 	return certificateData{
-		caKey:         caKey,
 		serverKey:     serverKey,
-		caCertDER:     caCertDER,
 		serverCertDER: serverCertDER,
 	}, nil
 }
 
-// saveCertificates 保存所有证书文件
-func saveCertificates(data certificateData, certPath, keyPath, caCertPath string) error {
-	// 保存 CA 证书
-	if err := writePem(caCertPath, "CERTIFICATE", data.caCertDER, 0644); err != nil {
-		return err
-	}
-
-	// 保存服务器证书和私钥
+// saveCertificates 保存服务器证书和私钥
+func saveCertificates(data certificateData, certPath, keyPath string) error {
 	if err := writePem(certPath, "CERTIFICATE", data.serverCertDER, 0644); err != nil {
 		return err
 	}
-	if err := writePem(keyPath, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(data.serverKey), 0600); err != nil {
+	if err := writePrivateKey(keyPath, data.serverKey); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// generateCertificateKeys 生成证书密钥对
-func generateCertificateKeys() (*rsa.PrivateKey, *rsa.PrivateKey, error) {
-	// 生成CA私钥
-	caKey, err := generateCAKey()
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// 生成服务器私钥
-	serverKey, err := generateServerKey()
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return caKey, serverKey, nil
-}
-
 // generateCAKey 生成CA密钥
-func generateCAKey() (*rsa.PrivateKey, error) {
-	return rsa.GenerateKey(rand.Reader, 2048)
+func generateCAKey(kt KeyType) (crypto.Signer, error) {
+	return generateKey(kt)
 }
 
 // generateServerKey 生成服务器密钥
-func generateServerKey() (*rsa.PrivateKey, error) {
-	return rsa.GenerateKey(rand.Reader, 2048)
+func generateServerKey(kt KeyType) (crypto.Signer, error) {
+	return generateKey(kt)
 }
 
 // generateCACertificate 生成CA证书
-func generateCACertificate(caKey *rsa.PrivateKey) ([]byte, error) {
+func generateCACertificate(caKey crypto.Signer) ([]byte, error) {
 	// 创建CA证书模板
-	caTemplate := createCACertificateTemplate()
+	caTemplate, err := createCACertificateTemplate(caKey.Public())
+	if err != nil {
+		return nil, err
+	}
 
 	// 生成CA证书
-	caCertDER, err := createCertificate(&caTemplate, &caTemplate, &caKey.PublicKey, caKey)
+	caCertDER, err := createCertificate(&caTemplate, &caTemplate, caKey.Public(), caKey)
 	if err != nil {
 		return nil, err
 	}
@@ -157,18 +177,29 @@ func generateCACertificate(caKey *rsa.PrivateKey) ([]byte, error) {
 }
 
 // createCACertificateTemplate 创建CA证书模板
-func createCACertificateTemplate() x509.Certificate {
+func createCACertificateTemplate(pub crypto.PublicKey) (x509.Certificate, error) {
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return x509.Certificate{}, err
+	}
+	keyID, err := subjectKeyID(pub)
+	if err != nil {
+		return x509.Certificate{}, err
+	}
+
+	now := time.Now()
 	return x509.Certificate{
-		SerialNumber: big.NewInt(time.Now().Unix()),
+		SerialNumber: serial,
 		Subject: pkix.Name{
 			CommonName: "Local HTTPS CA",
 		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().AddDate(10, 0, 0), // CA证书有效期10年
+		NotBefore:             now.Add(-clockSkewAllowance),
+		NotAfter:              now.AddDate(caValidityYears, 0, 0),
 		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		BasicConstraintsValid: true,
 		IsCA:                  true,
-	}
+		SubjectKeyId:          keyID,
+	}, nil
 }
 
 // createCertificate 创建证书
@@ -177,15 +208,15 @@ func createCertificate(template, parent *x509.Certificate, pub interface{}, priv
 }
 
 // generateServerCertificate 生成服务器证书
-func generateServerCertificate(caKey *rsa.PrivateKey, serverKey *rsa.PrivateKey) ([]byte, error) {
+func generateServerCertificate(ca *certAuthority, serverKey crypto.Signer, sans subjectAltNames, days int) ([]byte, error) {
 	// 创建服务器证书模板
-	serverTemplate := createServerCertificateTemplate()
-
-	// 从CA证书模板获取
-	caTemplate := createCACertificateTemplate()
+	serverTemplate, err := createServerCertificateTemplate(serverKey.Public(), ca.cert, sans, days)
+	if err != nil {
+		return nil, err
+	}
 
-	// 生成服务器证书
-	serverCertDER, err := createCertificate(&serverTemplate, &caTemplate, &serverKey.PublicKey, caKey)
+	// 使用 CA 证书签发服务器证书
+	serverCertDER, err := createCertificate(&serverTemplate, ca.cert, serverKey.Public(), ca.key)
 	if err != nil {
 		return nil, err
 	}
@@ -194,19 +225,45 @@ func generateServerCertificate(caKey *rsa.PrivateKey, serverKey *rsa.PrivateKey)
 }
 
 // createServerCertificateTemplate 创建服务器证书模板
-func createServerCertificateTemplate() x509.Certificate {
+func createServerCertificateTemplate(pub crypto.PublicKey, caCert *x509.Certificate, sans subjectAltNames, days int) (x509.Certificate, error) {
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return x509.Certificate{}, err
+	}
+	keyID, err := subjectKeyID(pub)
+	if err != nil {
+		return x509.Certificate{}, err
+	}
+	if days <= 0 {
+		days = DefaultLeafDays
+	}
+
+	now := time.Now()
 	return x509.Certificate{
-		SerialNumber: big.NewInt(time.Now().Unix()),
+		SerialNumber: serial,
 		Subject: pkix.Name{
 			CommonName: "localhost",
 		},
-		NotBefore:   time.Now(),
-		NotAfter:    time.Now().AddDate(30, 0, 0),
-		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-		DNSNames:    []string{"localhost", "127.0.0.1"},
-		IPAddresses: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		NotBefore:      now.Add(-clockSkewAllowance),
+		NotAfter:       now.AddDate(0, 0, days),
+		KeyUsage:       leafKeyUsage(pub),
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:       sans.dnsNames,
+		IPAddresses:    sans.ipAddresses,
+		SubjectKeyId:   keyID,
+		AuthorityKeyId: caCert.SubjectKeyId,
+	}, nil
+}
+
+// checkLeafDays 校验服务器证书有效天数
+func checkLeafDays(days int) error {
+	if days < 0 {
+		return fmt.Errorf("证书有效天数不能为负数: %d", days)
+	}
+	if days > maxBrowserLeafDays {
+		fmt.Printf("⚠️  证书有效期 %d 天超过 %d 天，Apple / Chrome 会拒绝该证书\n", days, maxBrowserLeafDays)
 	}
+	return nil
 }
 
 // writePem 写入 PEM 文件
@@ -224,6 +281,25 @@ func writePem(path, typ string, data []byte, mode os.FileMode) error {
 	return f.Close()
 }
 
+// readPem 读取 PEM 文件中第一个指定类型的数据块
+func readPem(path, typ string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s 中未找到 %s 数据", path, typ)
+		}
+		if block.Type == typ {
+			return block.Bytes, nil
+		}
+	}
+}
+
 // GetCertPaths 返回证书和私钥路径
 func GetCertPaths() (string, string) {
 	var certPath, keyPath string
@@ -267,6 +343,27 @@ func GetCACertPath() string {
 	}
 }
 
+// GetCAKeyPath 返回 CA 私钥路径
+//
+// CA 私钥与服务器证书放在同一配置目录，而不是与需要复制到手机的
+// CA 证书放在一起，避免分发证书时误带私钥。
+func GetCAKeyPath() string {
+	certPath, _ := GetCertPaths()
+	return filepath.Join(filepath.Dir(certPath), "ca-key.pem")
+}
+
+// GetACMECacheDir 返回 ACME 账户私钥与证书的缓存目录
+func GetACMECacheDir() string {
+	certPath, _ := GetCertPaths()
+	return filepath.Join(filepath.Dir(certPath), "acme")
+}
+
+// GetUploadStagingDir 返回可续传上传的暂存目录
+func GetUploadStagingDir() string {
+	certPath, _ := GetCertPaths()
+	return filepath.Join(filepath.Dir(certPath), "uploads")
+}
+
 // CheckCertificateExists 检查证书是否存在
 func CheckCertificateExists(path string) bool {
 	_, err := os.Stat(path)
@@ -283,6 +380,11 @@ func ShowInstructions(caCertPath string) {
 	fmt.Println("4. 安装证书 → CA证书")
 	fmt.Println("5. 选择证书文件，命名为 'hserve'")
 	fmt.Println()
+	fmt.Println("💻 其他系统:")
+	fmt.Println("  系统无法识别 .crt 时可导出其他格式:")
+	fmt.Println("  hserve cert export -format der   # Windows / Android（.cer）")
+	fmt.Println("  hserve cert export -format p12   # macOS 钥匙串")
+	fmt.Println()
 	fmt.Println("💡 温馨提示: 使用 deb 包安装会自动为您生成证书")
 	fmt.Println("🎮 启动服务器示例:")
 	fmt.Println("  cd /path/to/website")
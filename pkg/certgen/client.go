@@ -0,0 +1,172 @@
+package certgen
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ClientOptions 客户端证书签发选项
+type ClientOptions struct {
+	Name     string  // 客户端名称，写入证书 CN 并用作文件名
+	Days     int     // 有效天数，0 表示使用 DefaultLeafDays
+	KeyType  KeyType // 私钥类型
+	Password string  // PKCS#12 文件密码，为空时随机生成
+	OutDir   string  // 输出目录，为空时使用 GetClientCertDir
+}
+
+// ClientFiles 签发结果
+type ClientFiles struct {
+	CertPath string
+	KeyPath  string
+	P12Path  string
+	Password string
+}
+
+// IssueClientCertificate 使用已存储的 CA 签发客户端证书
+//
+// 同时生成 PEM 证书、私钥以及可直接导入 Android / 浏览器的 PKCS#12 文件。
+func IssueClientCertificate(opt ClientOptions) (ClientFiles, error) {
+	if !isValidClientName(opt.Name) {
+		return ClientFiles{}, fmt.Errorf("无效的客户端名称: %q（仅允许字母、数字、-、_ 和 .）", opt.Name)
+	}
+	if err := checkLeafDays(opt.Days); err != nil {
+		return ClientFiles{}, err
+	}
+
+	caKeyPath := GetCAKeyPath()
+	if !CheckCertificateExists(caKeyPath) {
+		return ClientFiles{}, fmt.Errorf("未找到 CA 私钥 %s，请先运行 hserve cert", caKeyPath)
+	}
+	ca, err := loadCA(GetCACertPath(), caKeyPath)
+	if err != nil {
+		return ClientFiles{}, err
+	}
+
+	key, err := generateKey(opt.KeyType)
+	if err != nil {
+		return ClientFiles{}, err
+	}
+	template, err := createClientCertificateTemplate(opt.Name, key.Public(), ca.cert, opt.Days)
+	if err != nil {
+		return ClientFiles{}, err
+	}
+	der, err := createCertificate(&template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		return ClientFiles{}, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return ClientFiles{}, err
+	}
+
+	password := opt.Password
+	if password == "" {
+		if password, err = randomPassword(); err != nil {
+			return ClientFiles{}, err
+		}
+	}
+
+	return saveClientFiles(opt, cert, key, ca.cert, password)
+}
+
+// saveClientFiles 保存客户端证书、私钥和 PKCS#12 文件
+func saveClientFiles(opt ClientOptions, cert *x509.Certificate, key crypto.Signer, caCert *x509.Certificate, password string) (ClientFiles, error) {
+	outDir := opt.OutDir
+	if outDir == "" {
+		outDir = GetClientCertDir()
+	}
+	if err := os.MkdirAll(outDir, 0700); err != nil {
+		return ClientFiles{}, err
+	}
+
+	files := ClientFiles{
+		CertPath: filepath.Join(outDir, opt.Name+".crt"),
+		KeyPath:  filepath.Join(outDir, opt.Name+".key"),
+		P12Path:  filepath.Join(outDir, opt.Name+".p12"),
+		Password: password,
+	}
+
+	if err := writePem(files.CertPath, "CERTIFICATE", cert.Raw, 0644); err != nil {
+		return ClientFiles{}, err
+	}
+	if err := writePrivateKey(files.KeyPath, key); err != nil {
+		return ClientFiles{}, err
+	}
+
+	p12, err := encodePKCS12(key, cert, []*x509.Certificate{caCert}, password)
+	if err != nil {
+		return ClientFiles{}, err
+	}
+	if err := os.WriteFile(files.P12Path, p12, 0600); err != nil {
+		return ClientFiles{}, err
+	}
+
+	return files, nil
+}
+
+// createClientCertificateTemplate 创建客户端证书模板
+func createClientCertificateTemplate(name string, pub crypto.PublicKey, caCert *x509.Certificate, days int) (x509.Certificate, error) {
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return x509.Certificate{}, err
+	}
+	keyID, err := subjectKeyID(pub)
+	if err != nil {
+		return x509.Certificate{}, err
+	}
+	if days <= 0 {
+		days = DefaultLeafDays
+	}
+
+	now := time.Now()
+	return x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:         name,
+			OrganizationalUnit: []string{"hserve client"},
+		},
+		NotBefore:      now.Add(-clockSkewAllowance),
+		NotAfter:       now.AddDate(0, 0, days),
+		KeyUsage:       leafKeyUsage(pub),
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		SubjectKeyId:   keyID,
+		AuthorityKeyId: caCert.SubjectKeyId,
+	}, nil
+}
+
+// GetClientCertDir 返回客户端证书默认输出目录
+func GetClientCertDir() string {
+	certPath, _ := GetCertPaths()
+	return filepath.Join(filepath.Dir(certPath), "clients")
+}
+
+// isValidClientName 检查客户端名称是否可以安全地用作文件名
+func isValidClientName(name string) bool {
+	if name == "" || name == "." || name == ".." || len(name) > 64 {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// randomPassword 生成随机密码
+func randomPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,163 @@
+package certgen
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExportFormat 证书导出格式
+type ExportFormat string
+
+const (
+	ExportPEM ExportFormat = "pem" // Base64 文本，Linux / Android 通用
+	ExportDER ExportFormat = "der" // 二进制 .cer，Windows / Android 均可直接安装
+	ExportP12 ExportFormat = "p12" // PKCS#12，macOS 钥匙串 / Windows 证书管理器
+)
+
+// ExportKind 导出的证书种类
+type ExportKind string
+
+const (
+	ExportCA     ExportKind = "ca"     // 仅 CA 证书，永远不包含 CA 私钥
+	ExportServer ExportKind = "server" // 服务器证书（p12 中附带私钥和 CA）
+)
+
+// ExportOptions 证书导出选项
+type ExportOptions struct {
+	Kind     ExportKind
+	Format   ExportFormat
+	OutPath  string // 为空时使用 DefaultExportPath
+	Password string // p12 密码，为空时随机生成
+}
+
+// ExportResult 导出结果
+type ExportResult struct {
+	Path     string
+	Password string // 仅 p12 格式
+}
+
+// ParseExportFormat 解析导出格式
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToLower(name)); f {
+	case ExportPEM, ExportDER, ExportP12:
+		return f, nil
+	case "cer", "crt":
+		return ExportDER, nil
+	case "pfx", "pkcs12":
+		return ExportP12, nil
+	default:
+		return "", fmt.Errorf("不支持的导出格式: %s（可选: pem|der|p12）", name)
+	}
+}
+
+// ParseExportKind 解析导出的证书种类
+func ParseExportKind(name string) (ExportKind, error) {
+	switch k := ExportKind(strings.ToLower(name)); k {
+	case ExportCA, ExportServer:
+		return k, nil
+	default:
+		return "", fmt.Errorf("不支持的证书种类: %s（可选: ca|server）", name)
+	}
+}
+
+// DefaultExportPath 返回默认导出路径（与 CA 证书位于同一目录）
+func DefaultExportPath(kind ExportKind, format ExportFormat) string {
+	base := "hserve-ca"
+	if kind == ExportServer {
+		base = "hserve-server"
+	}
+
+	ext := map[ExportFormat]string{
+		ExportPEM: ".pem",
+		ExportDER: ".cer",
+		ExportP12: ".p12",
+	}[format]
+
+	return filepath.Join(filepath.Dir(GetCACertPath()), base+ext)
+}
+
+// Export 以指定格式导出 CA 或服务器证书
+func Export(opt ExportOptions) (ExportResult, error) {
+	outPath := opt.OutPath
+	if outPath == "" {
+		outPath = DefaultExportPath(opt.Kind, opt.Format)
+	}
+
+	ca, err := readCertificate(GetCACertPath())
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("读取 CA 证书失败: %w（请先运行 hserve cert）", err)
+	}
+
+	var certs []*x509.Certificate
+	switch opt.Kind {
+	case ExportCA:
+		certs = []*x509.Certificate{ca}
+	case ExportServer:
+		certPath, _ := GetCertPaths()
+		leaf, err := readCertificate(certPath)
+		if err != nil {
+			return ExportResult{}, fmt.Errorf("读取服务器证书失败: %w（请先运行 hserve cert）", err)
+		}
+		certs = []*x509.Certificate{leaf, ca}
+	default:
+		return ExportResult{}, fmt.Errorf("不支持的证书种类: %s", opt.Kind)
+	}
+
+	result := ExportResult{Path: outPath}
+	var data []byte
+	switch opt.Format {
+	case ExportPEM:
+		data = encodeCertificatesPEM(certs)
+	case ExportDER:
+		// DER 格式只能包含一张证书
+		data = certs[0].Raw
+	case ExportP12:
+		if result.Password = opt.Password; result.Password == "" {
+			if result.Password, err = randomPassword(); err != nil {
+				return ExportResult{}, err
+			}
+		}
+		if data, err = exportPKCS12(opt.Kind, certs, result.Password); err != nil {
+			return ExportResult{}, err
+		}
+	default:
+		return ExportResult{}, fmt.Errorf("不支持的导出格式: %s", opt.Format)
+	}
+
+	mode := os.FileMode(0644)
+	if opt.Kind == ExportServer && opt.Format == ExportP12 {
+		mode = 0600 // 包含服务器私钥
+	}
+	if err := os.WriteFile(outPath, data, mode); err != nil {
+		return ExportResult{}, err
+	}
+
+	return result, nil
+}
+
+// exportPKCS12 生成 PKCS#12：CA 导出为信任库，服务器证书附带私钥
+func exportPKCS12(kind ExportKind, certs []*x509.Certificate, password string) ([]byte, error) {
+	if kind == ExportCA {
+		return encodePKCS12TrustStore(certs, password)
+	}
+
+	_, keyPath := GetCertPaths()
+	key, err := readPrivateKey(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取服务器私钥失败: %w", err)
+	}
+	return encodePKCS12(key, certs[0], certs[1:], password)
+}
+
+// encodeCertificatesPEM 将证书依次编码为 PEM
+func encodeCertificatesPEM(certs []*x509.Certificate) []byte {
+	var out []byte
+	for _, cert := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return out
+}
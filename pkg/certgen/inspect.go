@@ -0,0 +1,274 @@
+package certgen
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxBrowserLeafDays 浏览器接受的服务器证书最长有效期（Apple / Chrome 限制为 398 天）
+const maxBrowserLeafDays = 398
+
+// CertificateInfo 单张证书的详细信息
+type CertificateInfo struct {
+	Path              string    `json:"path"`
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serial_number"`
+	IsCA              bool      `json:"is_ca"`
+	DNSNames          []string  `json:"dns_names,omitempty"`
+	IPAddresses       []string  `json:"ip_addresses,omitempty"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	DaysRemaining     int       `json:"days_remaining"`
+	KeyType           KeyType   `json:"key_type"`
+	SignatureAlgo     string    `json:"signature_algorithm"`
+	SHA256Fingerprint string    `json:"sha256_fingerprint"`
+}
+
+// InspectReport 证书检查结果
+type InspectReport struct {
+	Server     *CertificateInfo `json:"server,omitempty"`
+	CA         *CertificateInfo `json:"ca,omitempty"`
+	KeyMatches bool             `json:"key_matches"`
+	ChainValid bool             `json:"chain_valid"`
+	Errors     []string         `json:"errors,omitempty"`
+	Warnings   []string         `json:"warnings,omitempty"`
+}
+
+// Healthy 没有错误时返回 true（警告不影响结果）
+func (r *InspectReport) Healthy() bool {
+	return len(r.Errors) == 0
+}
+
+// Inspect 检查服务器证书、私钥和 CA 证书
+//
+// keyPath 或 caCertPath 为空时跳过对应的检查。
+func Inspect(certPath, keyPath, caCertPath string) *InspectReport {
+	report := &InspectReport{}
+	now := time.Now()
+
+	chain, err := readCertificates(certPath)
+	var leaf *x509.Certificate
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("读取服务器证书失败: %v", err))
+	} else {
+		leaf = chain[0]
+		report.Server = describeCertificate(certPath, leaf, now)
+		report.checkValidity("服务器证书", report.Server, now)
+		if !leaf.IsCA && leaf.NotAfter.Sub(leaf.NotBefore) > maxBrowserLeafDays*24*time.Hour {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("服务器证书有效期超过 %d 天，Apple / Chrome 会拒绝该证书，请运行 hserve cert renew", maxBrowserLeafDays))
+		}
+	}
+
+	ca := report.inspectCA(caCertPath, now)
+
+	if keyPath != "" {
+		if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("服务器私钥与证书不匹配: %v", err))
+		} else {
+			report.KeyMatches = true
+		}
+	}
+
+	if leaf != nil && ca != nil {
+		if err := verifyChain(leaf, chain[1:], ca, now); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("服务器证书无法通过 CA 验证: %v", err))
+		} else {
+			report.ChainValid = true
+		}
+	}
+
+	return report
+}
+
+// inspectCA 读取并检查 CA 证书，路径为空或证书不可用时返回 nil
+func (r *InspectReport) inspectCA(caCertPath string, now time.Time) *x509.Certificate {
+	if caCertPath == "" {
+		return nil
+	}
+
+	ca, err := readCertificate(caCertPath)
+	if err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("读取 CA 证书失败: %v", err))
+		return nil
+	}
+
+	r.CA = describeCertificate(caCertPath, ca, now)
+	r.checkValidity("CA 证书", r.CA, now)
+	if !ca.IsCA {
+		r.Errors = append(r.Errors, fmt.Sprintf("%s 不是 CA 证书", caCertPath))
+		return nil
+	}
+	return ca
+}
+
+// checkValidity 检查证书是否已过期或即将过期
+func (r *InspectReport) checkValidity(name string, info *CertificateInfo, now time.Time) {
+	switch {
+	case now.Before(info.NotBefore):
+		r.Errors = append(r.Errors, fmt.Sprintf("%s尚未生效（生效时间 %s）", name, info.NotBefore.Format(time.RFC3339)))
+	case now.After(info.NotAfter):
+		r.Errors = append(r.Errors, fmt.Sprintf("%s已于 %s 过期", name, info.NotAfter.Format(time.RFC3339)))
+	case info.DaysRemaining < DefaultRenewWithinDays:
+		r.Warnings = append(r.Warnings, fmt.Sprintf("%s将在 %d 天后过期", name, info.DaysRemaining))
+	}
+}
+
+// verifyChain 验证服务器证书是否由 CA 签发，证书文件中附带的中间证书参与验证
+func verifyChain(leaf *x509.Certificate, intermediates []*x509.Certificate, ca *x509.Certificate, now time.Time) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	pool := x509.NewCertPool()
+	for _, cert := range intermediates {
+		pool.AddCert(cert)
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: pool,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}
+
+// readCertificate 读取并解析 PEM 证书
+func readCertificate(path string) (*x509.Certificate, error) {
+	der, err := readPem(path, "CERTIFICATE")
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// readCertificates 读取 PEM 文件中的全部证书，第一张为服务器证书
+func readCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s 中未找到 CERTIFICATE 数据", path)
+	}
+	return certs, nil
+}
+
+// describeCertificate 提取证书的详细信息
+func describeCertificate(path string, cert *x509.Certificate, now time.Time) *CertificateInfo {
+	info := &CertificateInfo{
+		Path:              path,
+		Subject:           cert.Subject.String(),
+		Issuer:            cert.Issuer.String(),
+		SerialNumber:      formatHex(cert.SerialNumber.Bytes()),
+		IsCA:              cert.IsCA,
+		DNSNames:          cert.DNSNames,
+		NotBefore:         cert.NotBefore,
+		NotAfter:          cert.NotAfter,
+		DaysRemaining:     int(cert.NotAfter.Sub(now).Hours() / 24),
+		KeyType:           KeyTypeOf(cert.PublicKey),
+		SignatureAlgo:     cert.SignatureAlgorithm.String(),
+		SHA256Fingerprint: Fingerprint(cert),
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	return info
+}
+
+// Fingerprint 返回证书的 SHA-256 指纹（冒号分隔的大写十六进制）
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return formatHex(sum[:])
+}
+
+// formatHex 将字节格式化为 AA:BB:CC 形式
+func formatHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = strings.ToUpper(hex.EncodeToString([]byte{v}))
+	}
+	return strings.Join(parts, ":")
+}
+
+// PrintReport 以易读格式输出检查结果
+func PrintReport(r *InspectReport) {
+	if r.Server != nil {
+		fmt.Println("📄 服务器证书")
+		printCertificateInfo(r.Server)
+		fmt.Println()
+	}
+	if r.CA != nil {
+		fmt.Println("🏛️  CA 证书")
+		printCertificateInfo(r.CA)
+		fmt.Println()
+	}
+
+	fmt.Println("🩺 健康检查")
+	fmt.Printf("  私钥匹配: %s\n", yesNo(r.KeyMatches))
+	fmt.Printf("  证书链验证: %s\n", yesNo(r.ChainValid))
+	for _, w := range r.Warnings {
+		fmt.Println("  ⚠️ ", w)
+	}
+	for _, e := range r.Errors {
+		fmt.Println("  ❌", e)
+	}
+	if r.Healthy() && len(r.Warnings) == 0 {
+		fmt.Println("  ✅ 一切正常")
+	}
+}
+
+// printCertificateInfo 输出单张证书信息
+func printCertificateInfo(info *CertificateInfo) {
+	fmt.Printf("  文件:     %s\n", info.Path)
+	fmt.Printf("  主题:     %s\n", info.Subject)
+	fmt.Printf("  颁发者:   %s\n", info.Issuer)
+	fmt.Printf("  序列号:   %s\n", info.SerialNumber)
+	if len(info.DNSNames) > 0 {
+		fmt.Printf("  DNS 名称: %s\n", strings.Join(info.DNSNames, ", "))
+	}
+	if len(info.IPAddresses) > 0 {
+		fmt.Printf("  IP 地址:  %s\n", strings.Join(info.IPAddresses, ", "))
+	}
+	fmt.Printf("  有效期:   %s ~ %s（剩余 %d 天）\n",
+		info.NotBefore.Local().Format("2006-01-02 15:04"),
+		info.NotAfter.Local().Format("2006-01-02 15:04"),
+		info.DaysRemaining)
+	fmt.Printf("  密钥类型: %s\n", info.KeyType)
+	fmt.Printf("  签名算法: %s\n", info.SignatureAlgo)
+	fmt.Printf("  SHA-256:  %s\n", info.SHA256Fingerprint)
+}
+
+// yesNo 将布尔值格式化为 ✅ / ❌
+func yesNo(ok bool) string {
+	if ok {
+		return "✅ 是"
+	}
+	return "❌ 否"
+}
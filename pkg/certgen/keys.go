@@ -0,0 +1,162 @@
+package certgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyType 证书私钥类型
+type KeyType string
+
+const (
+	KeyTypeRSA2048   KeyType = "rsa2048"
+	KeyTypeRSA4096   KeyType = "rsa4096"
+	KeyTypeECDSAP256 KeyType = "ecdsa-p256"
+	KeyTypeECDSAP384 KeyType = "ecdsa-p384"
+	KeyTypeEd25519   KeyType = "ed25519"
+
+	// DefaultKeyType 未指定时使用的私钥类型
+	DefaultKeyType = KeyTypeRSA2048
+)
+
+// KeyTypes 返回所有支持的私钥类型
+func KeyTypes() []KeyType {
+	return []KeyType{KeyTypeRSA2048, KeyTypeRSA4096, KeyTypeECDSAP256, KeyTypeECDSAP384, KeyTypeEd25519}
+}
+
+// ParseKeyType 解析私钥类型名称，空字符串返回默认类型
+func ParseKeyType(name string) (KeyType, error) {
+	if name == "" {
+		return DefaultKeyType, nil
+	}
+
+	for _, kt := range KeyTypes() {
+		if strings.EqualFold(name, string(kt)) {
+			return kt, nil
+		}
+	}
+
+	return "", fmt.Errorf("不支持的私钥类型: %s（可选: %s）", name, joinKeyTypes())
+}
+
+// joinKeyTypes 返回以 | 分隔的私钥类型列表
+func joinKeyTypes() string {
+	var names []string
+	for _, kt := range KeyTypes() {
+		names = append(names, string(kt))
+	}
+	return strings.Join(names, "|")
+}
+
+// KeyTypeOf 根据公钥返回私钥类型，无法识别时返回空字符串
+//
+// 不支持生成的长度或曲线（例如 mkcert 的 RSA-3072 CA、P-521 证书）按实际值返回，
+// 如 rsa3072、ecdsa-p521，不会归入相近的类型，可用 Supported 判断能否按该类型生成。
+func KeyTypeOf(pub crypto.PublicKey) KeyType {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return KeyType(fmt.Sprintf("rsa%d", k.N.BitLen()))
+	case *ecdsa.PublicKey:
+		return KeyType("ecdsa-" + strings.ToLower(strings.ReplaceAll(k.Curve.Params().Name, "-", "")))
+	case ed25519.PublicKey:
+		return KeyTypeEd25519
+	default:
+		return ""
+	}
+}
+
+// Supported 检查是否可以生成该类型的私钥
+func (kt KeyType) Supported() bool {
+	for _, supported := range KeyTypes() {
+		if kt == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// generateKey 按类型生成私钥
+func generateKey(kt KeyType) (crypto.Signer, error) {
+	switch kt {
+	case KeyTypeRSA2048, "":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyTypeECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyTypeEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("不支持的私钥类型: %s", kt)
+	}
+}
+
+// leafKeyUsage 返回服务器 / 客户端证书的密钥用途
+//
+// 只有 RSA 密钥交换需要 KeyEncipherment，ECDSA / Ed25519 证书不应设置该位。
+func leafKeyUsage(pub crypto.PublicKey) x509.KeyUsage {
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		return x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature
+	}
+	return x509.KeyUsageDigitalSignature
+}
+
+// writePrivateKey 以 PKCS#8 格式保存私钥
+func writePrivateKey(path string, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return writePem(path, "PRIVATE KEY", der, 0600)
+}
+
+// readPrivateKey 读取私钥文件，兼容 PKCS#8、旧版 PKCS#1 和 SEC1 格式
+func readPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s 中未找到私钥", path)
+		}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := key.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("%s 中的私钥类型不受支持", path)
+			}
+			return signer, nil
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		}
+	}
+}
+
+// publicKeysEqual 检查两个公钥是否相同
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	k, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.Equal(b)
+}
@@ -0,0 +1,50 @@
+package certgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestKeyTypeOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       func() (crypto.Signer, error)
+		want      KeyType
+		supported bool
+	}{
+		{"rsa2048", func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 2048) }, KeyTypeRSA2048, true},
+		{"rsa3072", func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 3072) }, "rsa3072", false},
+		{"p256", func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P256(), rand.Reader) }, KeyTypeECDSAP256, true},
+		{"p384", func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P384(), rand.Reader) }, KeyTypeECDSAP384, true},
+		{"p521", func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P521(), rand.Reader) }, "ecdsa-p521", false},
+		{"ed25519", func() (crypto.Signer, error) {
+			_, k, err := ed25519.GenerateKey(rand.Reader)
+			return k, err
+		}, KeyTypeEd25519, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.key()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := KeyTypeOf(key.Public())
+			if got != tt.want {
+				t.Fatalf("KeyTypeOf = %q, want %q", got, tt.want)
+			}
+			if got.Supported() != tt.supported {
+				t.Fatalf("Supported = %v, want %v", got.Supported(), tt.supported)
+			}
+		})
+	}
+
+	if got := KeyTypeOf(nil); got != "" || got.Supported() {
+		t.Fatalf("KeyTypeOf(nil) = %q", got)
+	}
+}
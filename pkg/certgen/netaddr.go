@@ -0,0 +1,67 @@
+package certgen
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// LocalIPs 返回本机所有已启用的非回环接口地址
+//
+// 链路本地地址（169.254.x.x / fe80::）需要附带网卡区域标识才能访问，
+// 写入证书没有意义，因此会被忽略。
+func LocalIPs() ([]net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !isUsableLocalIP(ipNet.IP) {
+				continue
+			}
+			ips = append(ips, ipNet.IP)
+		}
+	}
+
+	return ips, nil
+}
+
+// isUsableLocalIP 检查地址是否适合写入证书
+func isUsableLocalIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified()
+}
+
+// detectLocalNames 返回本机接口地址与主机名，用于自动填充证书名称
+func detectLocalNames() []string {
+	var names []string
+
+	ips, err := LocalIPs()
+	if err != nil {
+		fmt.Println("⚠️  获取网卡地址失败:", err)
+	}
+	for _, ip := range ips {
+		names = append(names, ip.String())
+	}
+
+	// 主机名不合法（例如包含空格）时直接忽略
+	if hostname, err := os.Hostname(); err == nil {
+		if _, err := normalizeDNSName(hostname); err == nil {
+			names = append(names, hostname)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,21 @@
+package certgen
+
+import (
+	"crypto"
+	"crypto/x509"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// encodePKCS12 将私钥、证书及 CA 打包为 PKCS#12
+//
+// 使用 3DES + SHA-1 MAC 的传统格式：Android 12 以下及部分旧版系统钥匙串
+// 无法导入 AES 加密的 PKCS#12 文件。
+func encodePKCS12(key crypto.Signer, cert *x509.Certificate, caCerts []*x509.Certificate, password string) ([]byte, error) {
+	return pkcs12.LegacyDES.Encode(key, cert, caCerts, password)
+}
+
+// encodePKCS12TrustStore 将仅含证书（无私钥）的信任库打包为 PKCS#12
+func encodePKCS12TrustStore(certs []*x509.Certificate, password string) ([]byte, error) {
+	return pkcs12.LegacyDES.EncodeTrustStore(certs, password)
+}
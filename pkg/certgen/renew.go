@@ -0,0 +1,95 @@
+package certgen
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// DefaultRenewWithinDays 剩余有效期少于该天数时续期
+const DefaultRenewWithinDays = 30
+
+// RenewOptions 服务器证书续期选项
+type RenewOptions struct {
+	Force  bool // 无论剩余有效期多少都重新签发
+	Within int  // 剩余天数少于该值时续期，0 表示使用 DefaultRenewWithinDays
+	Days   int  // 新证书有效天数，0 表示使用 DefaultLeafDays
+}
+
+// Renew 使用已存储的 CA 重新签发服务器证书
+//
+// 新证书保留原有名称与私钥类型，返回是否实际进行了续期。
+func Renew(opt RenewOptions) (bool, error) {
+	certPath, keyPath := GetCertPaths()
+	return RenewAt(certPath, keyPath, opt)
+}
+
+// RenewAt 使用已存储的 CA 重新签发指定路径的服务器证书
+func RenewAt(certPath, keyPath string, opt RenewOptions) (bool, error) {
+	leaf, err := readCertificate(certPath)
+	if err != nil {
+		return false, fmt.Errorf("读取服务器证书失败: %w（请先运行 hserve cert）", err)
+	}
+
+	if !opt.Force && !NeedsRenewal(leaf, opt.Within, time.Now()) {
+		return false, nil
+	}
+
+	caKeyPath := GetCAKeyPath()
+	if !CheckCertificateExists(caKeyPath) {
+		return false, fmt.Errorf("未找到 CA 私钥 %s，无法续期（可运行 hserve cert -force -new-ca 重新生成）", caKeyPath)
+	}
+	ca, err := loadCA(GetCACertPath(), caKeyPath)
+	if err != nil {
+		return false, err
+	}
+	// 只续期由 hserve CA 签发的证书，避免覆盖用户自带的证书
+	if err := leaf.CheckSignatureFrom(ca.cert); err != nil {
+		return false, fmt.Errorf("服务器证书不是由 hserve CA 签发，无法续期: %w", err)
+	}
+
+	if err := checkLeafDays(opt.Days); err != nil {
+		return false, err
+	}
+	sans, err := buildSubjectAltNames(CertificateNames(leaf))
+	if err != nil {
+		return false, err
+	}
+
+	keyType := KeyTypeOf(leaf.PublicKey)
+	if !keyType.Supported() {
+		return false, fmt.Errorf("服务器证书的私钥类型 %q 不受支持，无法按原类型续期（可运行 hserve cert -force -key-type %s 重新签发）", keyType, DefaultKeyType)
+	}
+
+	certData, err := createCertificateData(ca, keyType, sans, opt.Days)
+	if err != nil {
+		return false, err
+	}
+	if err := saveCertificates(certData, certPath, keyPath); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NeedsRenewal 检查证书是否需要续期
+//
+// 剩余有效期不足 within 天，或有效期超过浏览器上限的证书都需要续期。
+func NeedsRenewal(cert *x509.Certificate, within int, now time.Time) bool {
+	if within <= 0 {
+		within = DefaultRenewWithinDays
+	}
+	if cert.NotAfter.Sub(cert.NotBefore) > maxBrowserLeafDays*24*time.Hour {
+		return true
+	}
+	return now.AddDate(0, 0, within).After(cert.NotAfter)
+}
+
+// CertificateNames 返回证书中的全部 DNS 名称与 IP 地址
+func CertificateNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
@@ -0,0 +1,84 @@
+package certgen
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// defaultSANs 服务器证书默认包含的名称
+var defaultSANs = []string{"localhost", "127.0.0.1", "::1"}
+
+// subjectAltNames 服务器证书的主题备用名称
+type subjectAltNames struct {
+	dnsNames    []string
+	ipAddresses []net.IP
+}
+
+// buildSubjectAltNames 将默认名称与自定义名称合并，并按 IP / 域名分类
+func buildSubjectAltNames(extra []string) (subjectAltNames, error) {
+	var sans subjectAltNames
+	seen := make(map[string]bool)
+
+	for _, name := range append(append([]string{}, defaultSANs...), extra...) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(name); ip != nil {
+			key := ip.String()
+			if !seen[key] {
+				seen[key] = true
+				sans.ipAddresses = append(sans.ipAddresses, ip)
+			}
+			continue
+		}
+
+		dnsName, err := normalizeDNSName(name)
+		if err != nil {
+			return subjectAltNames{}, err
+		}
+		if !seen[dnsName] {
+			seen[dnsName] = true
+			sans.dnsNames = append(sans.dnsNames, dnsName)
+		}
+	}
+
+	return sans, nil
+}
+
+// normalizeDNSName 校验并规范化域名，通配符只允许出现在最左侧标签
+func normalizeDNSName(name string) (string, error) {
+	dnsName := strings.ToLower(strings.TrimSuffix(name, "."))
+
+	labels := strings.Split(dnsName, ".")
+	for i, label := range labels {
+		if label == "*" && i == 0 && len(labels) > 1 {
+			continue
+		}
+		if !isValidDNSLabel(label) {
+			return "", fmt.Errorf("无效的证书名称: %q", name)
+		}
+	}
+
+	return dnsName, nil
+}
+
+// isValidDNSLabel 检查单个域名标签是否合法
+func isValidDNSLabel(label string) bool {
+	if label == "" || len(label) > 63 {
+		return false
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, c := range label {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
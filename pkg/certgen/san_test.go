@@ -0,0 +1,73 @@
+package certgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSubjectAltNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   []string
+		wantDNS []string
+		wantIPs []string
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			wantDNS: []string{"localhost"},
+			wantIPs: []string{"127.0.0.1", "::1"},
+		},
+		{
+			name:    "custom names",
+			extra:   []string{"192.168.1.20", "nas.local", " files.example.com ", "fe80::1"},
+			wantDNS: []string{"localhost", "nas.local", "files.example.com"},
+			wantIPs: []string{"127.0.0.1", "::1", "192.168.1.20", "fe80::1"},
+		},
+		{
+			name:    "normalized and deduplicated",
+			extra:   []string{"NAS.Local.", "nas.local", "LOCALHOST", "::0001", "127.0.0.1", ""},
+			wantDNS: []string{"localhost", "nas.local"},
+			wantIPs: []string{"127.0.0.1", "::1"},
+		},
+		{
+			name:    "wildcard",
+			extra:   []string{"*.example.com"},
+			wantDNS: []string{"localhost", "*.example.com"},
+			wantIPs: []string{"127.0.0.1", "::1"},
+		},
+		{name: "wildcard not leftmost", extra: []string{"a.*.example.com"}, wantErr: true},
+		{name: "bare wildcard", extra: []string{"*"}, wantErr: true},
+		{name: "empty label", extra: []string{"a..example.com"}, wantErr: true},
+		{name: "leading hyphen", extra: []string{"-nas.local"}, wantErr: true},
+		{name: "invalid character", extra: []string{"nas local"}, wantErr: true},
+		{name: "url instead of name", extra: []string{"https://nas.local"}, wantErr: true},
+		{name: "label too long", extra: []string{"a123456789012345678901234567890123456789012345678901234567890123.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sans, err := buildSubjectAltNames(tt.extra)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", sans)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(sans.dnsNames, tt.wantDNS) {
+				t.Errorf("dnsNames = %v, want %v", sans.dnsNames, tt.wantDNS)
+			}
+			var ips []string
+			for _, ip := range sans.ipAddresses {
+				ips = append(ips, ip.String())
+			}
+			if !reflect.DeepEqual(ips, tt.wantIPs) {
+				t.Errorf("ipAddresses = %v, want %v", ips, tt.wantIPs)
+			}
+		})
+	}
+}